@@ -0,0 +1,133 @@
+// Command lcaaj transcribes LCAAJ key notation to IPA line by line.
+//
+// Usage:
+//
+//	lcaaj [-format plain|jsonl|csv|tsv] [-column name] [-no-header] [-notations expand|strip] [-alphabet name] [-normalize nfc|nfd] [-key file] [file ...]
+//
+// With no files, or with "-", it reads standard input. The -alphabet flag
+// writes plain output in another alphabet than IPA and adds that form to
+// jsonl output: one of ipa, x-sampa, tipa, ascii, yivo or yiddish.
+//
+// With -format csv or tsv the input is a spreadsheet export instead. The
+// notation in the column named by -column, a header name or a number
+// counting from 1, is transcribed in every row, and the rows are written
+// with ipa and annotations columns added, and a column in the alphabet
+// chosen if it is not IPA.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/sammyshear/lcaaj-transcriber/internal"
+)
+
+// maxLine is the longest input line accepted, in bytes.
+const maxLine = 1 << 20
+
+func main() {
+	format := flag.String("format", "plain", "output format: plain or jsonl, or csv or tsv to transcribe a table")
+	column := flag.String("column", "", "with -format csv or tsv, the column holding the notation: a header name or a number from 1 (default \"response\" or the first)")
+	noHeader := flag.Bool("no-header", false, "with -format csv or tsv, the first row is data rather than column names")
+	notations := flag.String("notations", "expand", "expand notations to their glosses or strip them: expand or strip")
+	alphabetName := flag.String("alphabet", "ipa", "alphabet to write transcriptions in: ipa, x-sampa, tipa, ascii, yivo or yiddish")
+	normalize := flag.String("normalize", "", "Unicode normalization form of the output: nfc or nfd (default none)")
+	keyPath := flag.String("key", os.Getenv("LCAAJ_KEY"), "transcription key file to use instead of the built-in key (env LCAAJ_KEY)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("lcaaj: ")
+
+	if *keyPath != "" {
+		k, err := internal.LoadKey(*keyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		internal.SetKey(k)
+	}
+
+	switch *format {
+	case "plain", "jsonl", "csv", "tsv":
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+	if *notations != "expand" && *notations != "strip" {
+		log.Fatalf("unknown notations mode %q", *notations)
+	}
+	alphabet, ok := internal.LookupAlphabet(*alphabetName)
+	if !ok {
+		log.Fatalf("unknown alphabet %q", *alphabetName)
+	}
+	normalization, err := internal.ParseNormalization(*normalize)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := internal.Options{StripNotations: *notations == "strip", Alphabet: alphabet, Normalization: normalization}
+	table := internal.TableOptions{Format: internal.TableFormat(*format), Column: *column, NoHeader: *noHeader, Options: opts}
+
+	files := flag.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for _, name := range files {
+		if err := transcribeFile(w, name, *format, table); err != nil {
+			w.Flush()
+			log.Fatal(err)
+		}
+	}
+}
+
+func transcribeFile(w io.Writer, name, format string, table internal.TableOptions) error {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return transcribe(w, r, name, format, table)
+}
+
+// transcribe transcribes the input r, read from the file name, to w.
+func transcribe(w io.Writer, r io.Reader, name, format string, table internal.TableOptions) error {
+	if format == "csv" || format == "tsv" {
+		if err := internal.TranscribeTable(context.Background(), w, r, table); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+
+	opts := table.Options
+	enc := json.NewEncoder(w)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxLine)
+	for sc.Scan() {
+		// Files saved on Windows end their lines with \r\n.
+		res := internal.Transcribe(strings.TrimSuffix(sc.Text(), "\r"), opts)
+		var err error
+		if format == "jsonl" {
+			err = enc.Encode(res)
+		} else {
+			_, err = fmt.Fprintln(w, res.Inline())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sammyshear/lcaaj-transcriber/internal"
+)
+
+func TestTranscribe(t *testing.T) {
+	const in = "a94 Q(R)\ns+\r\n\nQ(ED) x QP b\r\n"
+	tests := []struct {
+		name   string
+		format string
+		strip  bool
+		want   string
+	}{
+		{"plain", "plain", false, "a\u0306 rare\nʃ\n\neditor's comments follow: x b\n"},
+		{"strip", "plain", true, "a\u0306\nʃ\n\nb\n"},
+	}
+	for _, tt := range tests {
+		var w strings.Builder
+		table := internal.TableOptions{Options: internal.Options{StripNotations: tt.strip}}
+		if err := transcribe(&w, strings.NewReader(in), "in", tt.format, table); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := w.String(); got != tt.want {
+			t.Errorf("%s: output %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTranscribeJSONL(t *testing.T) {
+	for _, strip := range []bool{false, true} {
+		var w strings.Builder
+		table := internal.TableOptions{Options: internal.Options{StripNotations: strip}}
+		if err := transcribe(&w, strings.NewReader("a94 Q(R)\r\ns+\n"), "in", "jsonl", table); err != nil {
+			t.Fatal(err)
+		}
+
+		var results []internal.Result
+		sc := bufio.NewScanner(strings.NewReader(w.String()))
+		for sc.Scan() {
+			var res internal.Result
+			if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+				t.Fatalf("line %q: %v", sc.Text(), err)
+			}
+			results = append(results, res)
+		}
+		if len(results) != 2 {
+			t.Fatalf("strip %v: %d results, want 2:\n%s", strip, len(results), w.String())
+		}
+		if res := results[0]; res.Input != "a94 Q(R)" || res.IPA != "a\u0306" {
+			t.Errorf("strip %v: first result has input %q and IPA %q, want %q and %q", strip, res.Input, res.IPA, "a94 Q(R)", "a\u0306")
+		}
+		want := 1
+		if strip {
+			want = 0
+		}
+		if n := len(results[0].Annotations); n != want {
+			t.Errorf("strip %v: %d annotations, want %d", strip, n, want)
+		}
+		if res := results[1]; res.Input != "s+" || res.IPA != "ʃ" {
+			t.Errorf("strip %v: second result has input %q and IPA %q, want %q and %q", strip, res.Input, res.IPA, "s+", "ʃ")
+		}
+	}
+}
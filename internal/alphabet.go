@@ -0,0 +1,335 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// An Alphabet writes the phonetic content of a transcription in some
+// notation. IPA is the alphabet the key itself is written in; the others are
+// derived from it.
+type Alphabet interface {
+	// Name identifies the alphabet in requests and on the command line.
+	Name() string
+	// Title is the name shown to people.
+	Title() string
+	// Render renders the phonetic content of nodes, leaving out notations
+	// as RenderPhonetic does.
+	Render(nodes []Node) string
+}
+
+// alphabets lists the available alphabets in the order they are offered.
+var alphabets = []Alphabet{
+	ipaAlphabet{},
+	xsampaAlphabet,
+	tipaAlphabet,
+	asciiAlphabet,
+	yivoAlphabet,
+	yiddishAlphabet{},
+}
+
+// Alphabets returns the available alphabets, IPA first.
+func Alphabets() []Alphabet {
+	return alphabets
+}
+
+// LookupAlphabet returns the alphabet called name.
+func LookupAlphabet(name string) (Alphabet, bool) {
+	for _, a := range alphabets {
+		if a.Name() == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+type ipaAlphabet struct{}
+
+func (ipaAlphabet) Name() string  { return "ipa" }
+func (ipaAlphabet) Title() string { return "IPA" }
+
+func (ipaAlphabet) Render(nodes []Node) string {
+	return RenderPhonetic(nodes)
+}
+
+// markRule maps an IPA mark to the symbol of another alphabet. A wrapping
+// symbol is a macro that takes what it modifies as its argument.
+type markRule struct {
+	mark string
+	to   string
+	wrap bool
+}
+
+// symbolAlphabet is an alphabet that spells each IPA symbol and mark of a
+// segment with its own symbols.
+type symbolAlphabet struct {
+	name  string
+	title string
+	// symbols maps IPA base symbols. Symbols it does not list are written
+	// as they are.
+	symbols []symbolRule
+	marks   []markRule
+
+	primary, secondary, length, brk string
+
+	// other, if set, writes a rune of phonetic content the tables do not
+	// cover.
+	other func(r rune) string
+	// literal, if set, writes text that is not phonetic: codes a segment
+	// did not accept and text that is not part of any segment.
+	literal func(s string) string
+}
+
+func (a *symbolAlphabet) Name() string  { return a.name }
+func (a *symbolAlphabet) Title() string { return a.title }
+
+func (a *symbolAlphabet) Render(nodes []Node) string {
+	o := &output{}
+	writePhonetic(o, nodes, func(s *Segment) {
+		o.write(a.segment(s), Span{s.Pos, s.Len})
+	}, func(t *Text) {
+		o.write(a.text(t.Value), Span{t.Pos, len(t.Source)})
+	})
+	return o.b.String()
+}
+
+func (a *symbolAlphabet) segment(s *Segment) string {
+	var b strings.Builder
+	switch s.Stress {
+	case StressPrimary:
+		b.WriteString(a.primary)
+	case StressSecondary:
+		b.WriteString(a.secondary)
+	}
+
+	ipa := s.Base
+	for _, m := range s.marks() {
+		ipa += m.mark
+	}
+	b.WriteString(a.translit(ipa))
+
+	if s.Long {
+		b.WriteString(a.length)
+	}
+	if s.Break {
+		b.WriteString(a.brk)
+	}
+	for _, m := range s.Mods {
+		if !m.Applied {
+			b.WriteString(a.text(m.Code))
+		}
+	}
+	return b.String()
+}
+
+// translit spells the IPA symbols and marks in s. A wrapping mark wraps the
+// symbol before it, together with any marks already applied to that symbol.
+func (a *symbolAlphabet) translit(s string) string {
+	var out []string
+	for i := 0; i < len(s); {
+		if r, ok := matchRule(a.symbols, s[i:]); ok {
+			out = append(out, r.to)
+			i += len(r.from)
+			continue
+		}
+		if m, ok := a.matchMark(s[i:]); ok {
+			switch {
+			case m.wrap && len(out) > 0:
+				out[len(out)-1] = m.to + "{" + out[len(out)-1] + "}"
+			case m.wrap:
+				out = append(out, m.to+"{}")
+			default:
+				out = append(out, m.to)
+			}
+			i += len(m.mark)
+			continue
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		sym := string(r)
+		if a.other != nil {
+			sym = a.other(r)
+		}
+		if unicode.Is(unicode.Mn, r) && len(out) > 0 {
+			out[len(out)-1] += sym
+		} else {
+			out = append(out, sym)
+		}
+		i += n
+	}
+	return strings.Join(out, "")
+}
+
+func (a *symbolAlphabet) matchMark(s string) (markRule, bool) {
+	for _, m := range a.marks {
+		if strings.HasPrefix(s, m.mark) {
+			return m, true
+		}
+	}
+	return markRule{}, false
+}
+
+func (a *symbolAlphabet) text(s string) string {
+	if a.literal == nil {
+		return s
+	}
+	return a.literal(s)
+}
+
+// xsampaAlphabet is X-SAMPA, the ASCII alphabet most speech tools read.
+var xsampaAlphabet = &symbolAlphabet{
+	name:  "x-sampa",
+	title: "X-SAMPA",
+	symbols: []symbolRule{
+		{"ə", "@"}, {"ɪ", "I"}, {"ʌ", "V"}, {"ʔ", "?"},
+		{"ʃ", "S"}, {"ʒ", "Z"}, {"ʂ", "s`"}, {"ʐ", "z`"},
+	},
+	marks: []markRule{
+		{mark: "\u0306", to: "_X"},
+		{mark: "\u0303", to: "~"},
+		{mark: "\u031E", to: "_o"},
+		{mark: "\u031D", to: "_r"},
+		{mark: "\u0320", to: "_-"},
+		{mark: "\u031F", to: "_+"},
+		{mark: "\u0325", to: "_0"},
+		{mark: "\u032C", to: "_v"},
+		{mark: "\u033B", to: "_m"},
+		{mark: syllabicMark, to: "="},
+		{mark: "ˠ", to: "_G"},
+		{mark: "ʲ", to: "'"},
+		{mark: "ⁿ", to: "_n"},
+	},
+	primary:   `"`,
+	secondary: "%",
+	length:    ":",
+	brk:       ".",
+}
+
+// tipaAlphabet is the input of the LaTeX tipa package, for use inside
+// \textipa{...}. Text that is not phonetic is set in roman, since tipa reads
+// digits and capitals as IPA symbols.
+var tipaAlphabet = &symbolAlphabet{
+	name:  "tipa",
+	title: "TIPA (LaTeX)",
+	symbols: []symbolRule{
+		{"ə", "@"}, {"ɪ", "I"}, {"ʌ", "2"}, {"ʔ", "P"},
+		{"ʃ", "S"}, {"ʒ", "Z"}, {"ʂ", `\:s`}, {"ʐ", `\:z`},
+	},
+	marks: []markRule{
+		{mark: "\u0306", to: `\u`, wrap: true},
+		{mark: "\u0303", to: `\~`, wrap: true},
+		{mark: "\u031E", to: `\textlowering`, wrap: true},
+		{mark: "\u031D", to: `\textraising`, wrap: true},
+		{mark: "\u0320", to: `\textsubbar`, wrap: true},
+		{mark: "\u031F", to: `\textsubplus`, wrap: true},
+		{mark: "\u0325", to: `\textsubring`, wrap: true},
+		{mark: "\u032C", to: `\textsubwedge`, wrap: true},
+		{mark: "\u033B", to: `\textsubsquare`, wrap: true},
+		{mark: syllabicMark, to: `\textsyllabic`, wrap: true},
+		{mark: "ˠ", to: `\textsuperscript{G}`},
+		{mark: "ʲ", to: `\textsuperscript{j}`},
+		{mark: "ⁿ", to: `\textsuperscript{n}`},
+	},
+	primary:   `"`,
+	secondary: `""`,
+	length:    ":",
+	brk:       ".",
+	literal:   tipaLiteral,
+}
+
+// tipaLiteral sets s in roman, escaping the characters LaTeX treats
+// specially.
+func tipaLiteral(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(`\textrm{`)
+	for _, r := range s {
+		switch r {
+		case '#', '$', '%', '&', '_', '{', '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\\':
+			b.WriteString(`\textbackslash{}`)
+		case '^':
+			b.WriteString(`\textasciicircum{}`)
+		case '~':
+			b.WriteString(`\textasciitilde{}`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// asciiAlphabet is the Kirshenbaum ASCII IPA, with diacritics it has no
+// symbol for spelled out in angle brackets. Anything else outside ASCII is
+// written as <U+XXXX>, so the output is always plain ASCII.
+var asciiAlphabet = &symbolAlphabet{
+	name:  "ascii",
+	title: "ASCII (Kirshenbaum)",
+	symbols: []symbolRule{
+		{"ə", "@"}, {"ɪ", "I"}, {"ʌ", "V"}, {"ʔ", "?"},
+		{"ʃ", "S"}, {"ʒ", "Z"}, {"ʂ", "s."}, {"ʐ", "z."},
+	},
+	marks: []markRule{
+		{mark: "\u0306", to: "<xsh>"},
+		{mark: "\u0303", to: "~"},
+		{mark: "\u031E", to: "<lwr>"},
+		{mark: "\u031D", to: "<rsd>"},
+		{mark: "\u0320", to: "<ret>"},
+		{mark: "\u031F", to: "<adv>"},
+		{mark: "\u0325", to: "<o>"},
+		{mark: "\u032C", to: "<v>"},
+		{mark: "\u033B", to: "<lam>"},
+		{mark: syllabicMark, to: "-"},
+		{mark: "ˠ", to: "<vzd>"},
+		{mark: "ʲ", to: ";"},
+		{mark: "ⁿ", to: "<nsl>"},
+	},
+	primary:   "'",
+	secondary: ",",
+	length:    ":",
+	brk:       ".",
+	other:     asciiRune,
+	literal:   asciiLiteral,
+}
+
+func asciiRune(r rune) string {
+	if r < utf8.RuneSelf {
+		return string(r)
+	}
+	return fmt.Sprintf("<U+%04X>", r)
+}
+
+func asciiLiteral(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteString(asciiRune(r))
+	}
+	return b.String()
+}
+
+// yivoAlphabet is the YIVO romanization of Yiddish. It only has letters for
+// Yiddish phonemes, so diacritics, stress, length and syllable breaks are
+// dropped and sounds it has no letter for are written as in IPA.
+var yivoAlphabet = &symbolAlphabet{
+	name:  "yivo",
+	title: "YIVO romanization",
+	symbols: []symbolRule{
+		{"ə", "e"}, {"ɪ", "i"}, {"ʌ", "o"}, {"ʔ", ""},
+		{"ʃ", "sh"}, {"ʒ", "zh"}, {"ʂ", "sh"}, {"ʐ", "zh"},
+		{"x", "kh"}, {"j", "y"},
+	},
+	other: yivoRune,
+}
+
+func yivoRune(r rune) string {
+	if unicode.In(r, unicode.Mn, unicode.Lm) {
+		return ""
+	}
+	return string(r)
+}
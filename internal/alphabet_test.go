@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAlphabets(t *testing.T) {
+	tests := []struct {
+		in       string
+		alphabet string
+		want     string
+	}{
+		{"s+a,,95n, c7", "x-sampa", `S"a.n= ts` + "`" + `_m`},
+		{"s+a,,95n, c7", "tipa", `S"a.\textsyllabic{n} t\textsubsquare{\:s}`},
+		{"s+a,,95n, c7", "ascii", "S'a.n- ts.<lam>"},
+		{"s+a,,95n, c7", "yivo", "shan tsh"},
+		{"a94+. b7 d8 p+", "x-sampa", "a_X~: b_G d' p_n"},
+		{"a94+. b7 d8 p+", "tipa", `\~{\u{a}}: b\textsuperscript{G} d\textsuperscript{j} p\textsuperscript{n}`},
+		{"b2 ( 7", "tipa", `\textsubring{b} \textrm{7}`},
+		{"3x1j Q(R) c+", "yivo", "ekhiy tsh"},
+		{"s+ab3s", "yiddish", "שאַבעס"},
+		{"ejn ojf bojx", "yiddish", "אײן אױף בױך"},
+		{"kac lebn, cimes", "yiddish", "קאַץ לעבן צימעס"},
+		{"vu 95iz", "yiddish", "װוּ איז"},
+		{"m1n Q(R) fa1f", "yiddish", "מין פֿאַיִף"},
+		{"mentʃ", "yiddish", "מענטש"},
+		{"ʃab3s", "yiddish", "שאַבעס"},
+		{"dʒ1n", "yiddish", "דזשין"},
+	}
+	for _, tt := range tests {
+		a, ok := LookupAlphabet(tt.alphabet)
+		if !ok {
+			t.Fatalf("LookupAlphabet(%q) failed", tt.alphabet)
+		}
+		if got := Transcribe(tt.in, Options{Alphabet: a}).Output; got != tt.want {
+			t.Errorf("Transcribe(%q) in %s = %q, want %q", tt.in, tt.alphabet, got, tt.want)
+		}
+	}
+}
+
+func TestAlphabetASCII(t *testing.T) {
+	for _, in := range keyInputs() {
+		res := Transcribe(in+" é", Options{Alphabet: asciiAlphabet})
+		for _, s := range []string{res.Output, res.Inline()} {
+			for i := 0; i < len(s); i++ {
+				if s[i] >= utf8.RuneSelf {
+					t.Errorf("Transcribe(%q) in ascii = %q, not ASCII", in, s)
+					break
+				}
+			}
+		}
+	}
+}
@@ -3,11 +3,11 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
-	"io"
+	"hash/fnv"
 	"net/http"
-	"regexp"
+	"slices"
+	"strconv"
 	"strings"
-	"unicode/utf8"
 
 	"github.com/sammyshear/lcaaj-transcriber/views"
 	datastar "github.com/starfederation/datastar/sdk/go"
@@ -15,268 +15,243 @@ import (
 
 type dataSignal struct {
 	Data string `json:"data"`
+	// Format selects the response format of APITranscribe; "json" is the
+	// same as sending Accept: application/json.
+	Format string `json:"format,omitempty"`
+	// Alphabet names an alphabet to write the transcription in besides IPA.
+	Alphabet string `json:"alphabet,omitempty"`
+	// Normalize names the Unicode normalization form of the output, "nfc"
+	// or "nfd".
+	Normalize string `json:"normalize,omitempty"`
+	// Question, Location and Informant identify the response when it is
+	// saved as a record.
+	Question  string `json:"question,omitempty"`
+	Location  string `json:"location,omitempty"`
+	Informant string `json:"informant,omitempty"`
+	// LineKeys identify the lines the page shows transcriptions of, as
+	// lineKey does, so that only lines that changed are transcribed again.
+	LineKeys []string `json:"lineKeys,omitempty"`
 }
 
-type RegexpKey struct {
-	*regexp.Regexp
-	name string
+// requestOptions returns the transcription options for the alphabet and
+// normalization names of a request.
+func requestOptions(alphabetName, normalize string) (Options, error) {
+	alphabet, err := alphabetNamed(alphabetName)
+	if err != nil {
+		return Options{}, err
+	}
+	normalization, err := ParseNormalization(normalize)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{Alphabet: alphabet, Normalization: normalization}, nil
 }
 
-var basicMap = map[string]string{
-	"3": "ə",
-	"1": "ɪ",
-	"6": "ʌ",
-	".": "ː",
+// alphabetNamed is LookupAlphabet that treats "" as no alphabet and reports
+// an unknown name as an error.
+func alphabetNamed(name string) (Alphabet, error) {
+	if name == "" {
+		return nil, nil
+	}
+	a, ok := LookupAlphabet(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown alphabet %q", name)
+	}
+	return a, nil
 }
 
-const (
-	Vowels                 = "([aeiouəɪʌ])"
-	VowelsFull             = "([aeiouəɪʌ][\\x{0306}\\x{0303}\\x{031E}\\x{031D}\\x{0320}\\x{031F}]?)"
-	Consonants             = "([ʔbcdfghjklmnprstvwxzʃʒ(tʃ)ʂ̻ʐ̻(tʂ̻)][\\x{207F}\\x{02B2}\\x{02E0}]?)"
-	HushedConsonants       = "([csz])"
-	NasalReleaseConsonants = "([bdfgkptv])"
-	UnvoicingConsonants    = "([bdgjlmnrvwz])"
-	VoicingConsonants      = "([cfhkpstx])"
-	VelarizingConsonants   = "([bdgjlmnrvwfhkptx])"
-	PalatalizingConsonants = "([bdgjlmnrvwfhkptxsczʃʒ(tʃ)ʂ̻ʐ̻(tʂ̻)])"
-	NonPhoneticNotation    = "%s(?<text>[A-Za-z\\d\\s]*)(QP)"
-	BaseNotation           = "(%s)"
-)
-
-var hushedMap = map[string]string{
-	"s": "ʃ",
-	"z": "ʒ",
-	"c": "tʃ",
+// wantsJSON reports whether the client asked for a JSON response.
+func wantsJSON(r *http.Request, data *dataSignal) bool {
+	return data.Format == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
 }
 
-var semiHushedMap = map[string]string{
-	"s": "ʂ̻",
-	"c": "tʂ̻",
-	"z": "ʐ̻",
-}
+// MaxExplainSize is the longest input APITranscribe explains, in bytes.
+const MaxExplainSize = 4 << 10
 
-var vowelKeys = []RegexpKey{
-	{regexp.MustCompile(Vowels + "(94)"), "%c\u0306"},
-	{regexp.MustCompile(Vowels + "(\\+)"), "%c\u0303"},
-	{regexp.MustCompile(Vowels + "(4)"), "%c\u031E"},
-	{regexp.MustCompile(Vowels + "(5)"), "%c\u031D"},
-	{regexp.MustCompile(Vowels + "(7)"), "%c\u0320"},
-	{regexp.MustCompile(Vowels + "(8)"), "%c\u031F"},
-	{regexp.MustCompile(VowelsFull + "(95)"), "%c."},
-	{regexp.MustCompile(VowelsFull + "(,)(,)"), "\u02C8%c"},
-	{regexp.MustCompile(VowelsFull + "(,)"), "\u02CC%c"},
+func APITranscribe(w http.ResponseWriter, r *http.Request) {
+	data := &dataSignal{}
+	if !readJSON(w, r, data) {
+		return
+	}
+	opts, err := requestOptions(data.Alphabet, data.Normalize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	// A trace only makes sense in JSON, so explain implies it.
+	opts.Explain = r.URL.Query().Get("explain") == "1"
+	if opts.Explain && len(data.Data) > MaxExplainSize {
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("input is longer than %d bytes, the most that can be explained", MaxExplainSize))
+		return
+	}
+	res, err := TranscribeContext(r.Context(), data.Data, opts)
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, fmt.Errorf("transcription not finished: %w", err))
+		return
+	}
+	if opts.Explain || wantsJSON(r, data) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(res.Inline()))
 }
 
-var consKeys = []RegexpKey{
-	{regexp.MustCompile(HushedConsonants + "(\\+)"), "hushed"},
-	{regexp.MustCompile(HushedConsonants + "(7)"), "semi-hushed"},
-	{regexp.MustCompile(UnvoicingConsonants + "(2)"), "%c\u0325"},
-	{regexp.MustCompile(VoicingConsonants + "(2)"), "%c\u032C"},
-	{regexp.MustCompile(VelarizingConsonants + "(7)"), "%c\u02E0"},
-	{regexp.MustCompile(PalatalizingConsonants + "(8)"), "%c\u02B2"},
-	{regexp.MustCompile(NasalReleaseConsonants + "(\\+)"), "%c\u207F"},
-	{regexp.MustCompile(Consonants + "(,)"), "%c\u0329"},
+func DatastarTranscribe(w http.ResponseWriter, r *http.Request) {
+	data := &dataSignal{}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+	if err := datastar.ReadSignals(r, data); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if len(data.Data) > MaxBodySize {
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("input is longer than %d bytes", MaxBodySize))
+		return
+	}
+	opts, err := requestOptions(data.Alphabet, data.Normalize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	sse := datastar.NewSSE(w, r)
+	lines := splitLines(data.Data)
+	keys := make([]string, len(lines))
+	for i, line := range lines {
+		keys[i] = lineKey(line, data)
+		if i < len(data.LineKeys) && data.LineKeys[i] == keys[i] {
+			continue
+		}
+		res, err := TranscribeContext(r.Context(), line, opts)
+		if err != nil {
+			// The line keys are not sent, so the page asks again.
+			return
+		}
+		info := transcriptionInfo(res)
+		info.Line = i + 1
+		if i < len(data.LineKeys) {
+			// The fragment replaces the element with its ID.
+			sse.MergeFragmentTempl(views.Transcription(info))
+		} else {
+			sse.MergeFragmentTempl(views.Transcription(info), datastar.WithSelectorID("lines"), datastar.WithMergeAppend())
+		}
+	}
+	for i := len(lines); i < len(data.LineKeys); i++ {
+		sse.RemoveFragments("#" + views.LineID(i+1))
+	}
+	signals, _ := json.Marshal(map[string][]string{"lineKeys": keys})
+	sse.MergeSignals(signals)
 }
 
-// keys for notations
-
-var notKeys = []RegexpKey{
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "0")), "question not asked"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, `(?:^|[^A-Za-z\d])(\+ BUT)`)), " yes but: %s"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, `(?:^|[^A-Za-z\d])(\- BUT)`)), " no but: %s"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\+\$)`), " yes\\, but doubtful"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\-\$)`), " no\\, but doubtful"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\+)`), " yes"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\-)`), " no"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\=)`), " self-corrected"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\#)`), " self-corrected"},
-	{regexp.MustCompile(`(?:^|[^A-Za-z\d])(\*)`), " QFQM"},
-	{regexp.MustCompile(`\$`), " query"},
-	{regexp.MustCompile(`(\|\|)`), " is different from"},
-	{regexp.MustCompile(`(\/\/)(?<text>[A-Za-z\d]*)`), "(%s)"},
-	{regexp.MustCompile(`(\)\+)`), " prompted and accepted"},
-	{regexp.MustCompile(`(\)\-)`), " prompted and rejected"},
-	{regexp.MustCompile(`(\)\=)`), " prompted and replaces preceding response"},
-	{regexp.MustCompile(`(\(\/)`), " relevant to another question number"},
-	{regexp.MustCompile(`(\(\$)`), " relevant to another geographic location"},
-	{regexp.MustCompile(`(\(\()`), " reference to dictionary"},
-	{regexp.MustCompile(`(\()`), " relevant to problem number in dialectology"},
-	{regexp.MustCompile("(CLN)"), ":"},
-	{regexp.MustCompile("(CM)"), "\\,"},
-	{regexp.MustCompile("(DRWG)"), "drawing in protocol book"},
-	{regexp.MustCompile("(EQ)"), " is identical with (in respect to some significant point)"},
-	{regexp.MustCompile("(MISPMP)"), " misprompted (editor's comment)"},
-	{regexp.MustCompile("(MISTD)"), " misunderstanding\\, informant's response does not apply to question (editor's comment)"},
-	{regexp.MustCompile("(OVRPMP)"), " overprompted (editor's comment)"},
-	{regexp.MustCompile("(SC)"), ";"},
-	{regexp.MustCompile("(XX)"), " (sic)"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ADJ)")), "adjective"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(AMER)")), "american yiddish development"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ANG)")), "anglicism"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(AP)")), "applies to"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(\-AP)`)), "does not apply to"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(BF)")), "yes, fragment in book"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(B)")), "yes, text in protocol book"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(CF)")), "interviewer's comment: compare"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(DG)")), "disgust"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(EDS)")), "editor's query"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(EDN)")), "editor disagrees"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(ED)")), "editor's comments follow: %s"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ELSW)")), "elsewhere"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(EM)")), "emphatic"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(ENG)")), "explanation in english: %s"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ETC)")), "etc\\:"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ET)")), "etymology supplied by informant"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(FR)")), "yes, fragment on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(F\/Y)`)), "response of wife or other female bystander"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(GERM)")), "Informant’s statement that word is not Yiddish but German"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(GLE)")), "informant's explanation in English: "},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(GLY)")), "informant's explanation in Yiddish: "},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(GL)")), "gloss"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(HUM)")), "amusing"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(HUNG)")), "informant's statement that word is not Ydidish but Hungarian"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(H)")), "heard but not used"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(INF)")), "infinitive"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(I GL)")), "Interviewer's Summary: %s"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(I)")), "interviewer's comments follow: %s"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(K)")), "known"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(\-K)`)), "unknown"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(LAT)")), "not on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(LIT)")), "literary"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(MEMX)")), "informant's surpise at own recollection"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(M\/Y)`)), "response by husband or other male bystander"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(NEX)")), "did not exist"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(NN)")), "notVeryNew"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(NOUN)")), "noun"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(NP)")), "unprompted answer to prompted question"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(NT)")), "not on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(OF)")), "oldfashioned"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(OOF)")), "Very Oldfashioned"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(OTW)")), "Otherwise"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(POL)")), "Informant's statement that word is not Yiddish but Polish"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(Q)")), "Check answer on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(RR)")), "very rare"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(RUM)")), "Informant's statement that word is not Yiddish but Rumanian"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(RUS)")), "Informant's statement that word is not Yiddish but Russian"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(RTR)")), "rather"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(R)")), "rare"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(SMT)")), "notSometimes"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(SYN)")), "synonym"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(S)")), "said by: %s"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(TA)")), "tape audited"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(TF)")), "yes, fragment on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(T)")), "yes, text on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(\-T)`)), "text not on tape"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(UU)")), "VeryCommon"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(U)")), "Usual"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, `Q(\-U)`)), "Unusual"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(VB)")), "Verb"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(VL)")), "Vulgar"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(V)")), "Proverb"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(W)")), "used by: %s"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, `Q(\-W)`)), "not used by: %s"},
-	{regexp.MustCompile(fmt.Sprintf(NonPhoneticNotation, "Q(YID)")), "Informant's explanation in Yiddish but not necessarily verbatim or phoenetically accurate: %s"},
-	{regexp.MustCompile(fmt.Sprintf(BaseNotation, "Q(ZZ)")), "interviewer's comment: not elicitable"},
+// splitLines splits the input of the editor into lines. Empty input has
+// none.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
 }
 
-func Transcribe(data *dataSignal) string {
-	o := data.Data
+// lineKey identifies line as transcribed with the options of data: two
+// lines have the same key only if their transcriptions are the same.
+func lineKey(line string, data *dataSignal) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", data.Alphabet, data.Normalize, line)
+	return strconv.FormatUint(h.Sum64(), 36)
+}
 
-	for k, v := range basicMap {
-		o = strings.ReplaceAll(o, k, v)
+// AlphabetOptions lists the alphabets for the choice on the index page.
+func AlphabetOptions() []views.Option {
+	var opts []views.Option
+	for _, a := range alphabets {
+		opts = append(opts, views.Option{Value: a.Name(), Label: a.Title()})
 	}
+	return opts
+}
 
-	for _, k := range vowelKeys {
-		v := k.name
-		o = k.ReplaceAllStringFunc(o, func(s string) string {
-			r, _ := utf8.DecodeRuneInString(s)
-			_, lastSize := utf8.DecodeLastRuneInString(s)
-			woLastRune := s[:len(s)-lastSize]
-			if c, size := utf8.DecodeLastRuneInString(woLastRune); c != r && size != 0 && c != ',' && c != '9' {
-				return fmt.Sprintf(v+"%c", r, c)
-			} else if c == ',' {
-				woLastRune = woLastRune[:len(woLastRune)-size]
-				if cr, size := utf8.DecodeLastRuneInString(woLastRune); cr != r && size != 0 {
-					return fmt.Sprintf(v+"%c", r, cr)
-				}
-			} else if c == '9' {
-				woLastRune = woLastRune[:len(woLastRune)-size]
-				if cr, size := utf8.DecodeLastRuneInString(woLastRune); cr != r && size != 0 {
-					return fmt.Sprintf(v+"%c", r, cr)
-				}
-			}
-			return fmt.Sprintf(v, r)
-		})
+// transcriptionInfo prepares res for display.
+func transcriptionInfo(res *Result) views.TranscriptionInfo {
+	info := views.TranscriptionInfo{}
+	ipa := []rune(res.IPA)
+	for i, al := range res.Alignment {
+		info.IPA = append(info.IPA, views.Piece{Text: string(ipa[al.Out : al.Out+al.OutLen]), Link: i})
 	}
-
-	for _, k := range consKeys {
-		v := k.name
-		o = k.ReplaceAllStringFunc(o, func(s string) string {
-			r, _ := utf8.DecodeRuneInString(s)
-			switch v {
-			case "hushed":
-				for key, val := range hushedMap {
-					if string(r) == key {
-						return val
-					}
-				}
-			case "semi-hushed":
-				for key, val := range semiHushedMap {
-					if string(r) == key {
-						return val
-					}
-				}
-			}
-			_, lastSize := utf8.DecodeLastRuneInString(s)
-			woLastRune := s[:len(s)-lastSize]
-			if c, size := utf8.DecodeLastRuneInString(woLastRune); c != r && size != 0 {
-				return fmt.Sprintf(v+"%c", r, c)
-			}
-			return fmt.Sprintf(v, r)
-		})
-
-		o = strings.ReplaceAll(o, "95", "ʔ")
-		o = strings.ReplaceAll(o, "c", "ts")
+	for _, a := range res.Annotations {
+		info.Annotations = append(info.Annotations, views.Annotation{Code: a.Code, Gloss: a.Gloss})
 	}
-
-	for _, k := range notKeys {
-		o = k.ReplaceAllStringFunc(o, func(s string) string {
-			ans := k.name
-			if strings.HasSuffix(ans, "%s") || strings.HasSuffix(ans, "(%s)") {
-				groupIndex := k.SubexpIndex("text")
-				matches := k.FindStringSubmatch(o)
-				return fmt.Sprintf(ans, matches[groupIndex])
-			}
-
-			return k.name
-		})
+	for _, d := range res.Diagnostics {
+		info.Diagnostics = append(info.Diagnostics, views.Diagnostic{Severity: string(d.Severity), Message: d.Message})
 	}
-
-	o = strings.ToLower(o)
-
-	o = strings.ReplaceAll(o, "\\,", ",")
-	o = strings.ReplaceAll(o, "\\:", ".")
-	return o
+	if res.alphabet != nil {
+		info.Output = res.Output
+		info.OutputTitle = res.alphabet.Title()
+	}
+	info.Source = sourceSpans(res)
+	return info
 }
 
-func APITranscribe(w http.ResponseWriter, r *http.Request) {
-	data := &dataSignal{}
-	b, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+// sourceSpans splits the input of res at the edges of its diagnostics and of
+// the input spans of its alignment. Where these overlap the first one wins.
+func sourceSpans(res *Result) []views.Span {
+	s := res.Input
+	cuts := []int{0, len(s)}
+	var diags, aligned []Span
+	for _, d := range res.Diagnostics {
+		cuts = append(cuts, d.Offset, d.Offset+d.Len)
+		diags = append(diags, Span{d.Offset, d.Len})
+	}
+	for _, a := range res.Alignment {
+		cuts = append(cuts, a.In, a.In+a.InLen)
+		aligned = append(aligned, Span{a.In, a.InLen})
+	}
+	slices.Sort(cuts)
+	cuts = slices.Compact(cuts)
+
+	// No span crosses a cut, so the span covering the start of a piece
+	// covers all of it.
+	diagAt := firstCover(len(s), diags)
+	alignAt := firstCover(len(s), aligned)
+	var spans []views.Span
+	for i := 0; i+1 < len(cuts); i++ {
+		from, to := cuts[i], cuts[i+1]
+		span := views.Span{Text: s[from:to], Link: alignAt[from]}
+		if d := diagAt[from]; d >= 0 {
+			span.Severity = string(res.Diagnostics[d].Severity)
+		}
+		spans = append(spans, span)
 	}
-	json.Unmarshal(b, data)
-	w.Write([]byte(Transcribe(data)))
+	return spans
 }
 
-func DatastarTranscribe(w http.ResponseWriter, r *http.Request) {
-	data := &dataSignal{}
-	err := datastar.ReadSignals(r, data)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+// firstCover returns, for each of n bytes, the index of the first of spans
+// that covers it, or -1. Bytes already covered are skipped, so long
+// overlapping spans, as of nested unterminated notations, cost no more than
+// short ones.
+func firstCover(n int, spans []Span) []int {
+	cover := make([]int, n)
+	// next[i] leads to the first byte at or after i not yet covered.
+	next := make([]int, n+1)
+	for i := range cover {
+		cover[i] = -1
+		next[i] = i
 	}
-	sse := datastar.NewSSE(w, r)
-	o := Transcribe(data)
-	sse.MergeFragmentTempl(views.Transcription(o), datastar.WithSelectorID("result"))
+	next[n] = n
+	find := func(i int) int {
+		for next[i] != i {
+			next[i] = next[next[i]]
+			i = next[i]
+		}
+		return i
+	}
+	for k, sp := range spans {
+		for i := find(sp.Pos); i < sp.Pos+sp.Len; i = find(i) {
+			cover[i] = k
+			next[i] = i + 1
+		}
+	}
+	return cover
 }
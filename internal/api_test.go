@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDatastarTranscribeLines(t *testing.T) {
+	post := func(data string, keys []string) (string, []string) {
+		t.Helper()
+		body, _ := json.Marshal(dataSignal{Data: data, LineKeys: keys})
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+		r.Header.Set("Datastar-Request", "true")
+		w := httptest.NewRecorder()
+		DatastarTranscribe(w, r)
+		m := regexp.MustCompile(`data: signals (.*)`).FindStringSubmatch(w.Body.String())
+		if m == nil {
+			t.Fatalf("no line keys in %s", w.Body)
+		}
+		var signals dataSignal
+		if err := json.Unmarshal([]byte(m[1]), &signals); err != nil {
+			t.Fatal(err)
+		}
+		return w.Body.String(), signals.LineKeys
+	}
+	lines := func(events string) []string {
+		var ids []string
+		for _, m := range regexp.MustCompile(`<li id="(line-\d+)"`).FindAllStringSubmatch(events, -1) {
+			ids = append(ids, m[1])
+		}
+		return ids
+	}
+
+	events, keys := post("a94\nu\nQ(ED) x", nil)
+	if got := lines(events); strings.Join(got, " ") != "line-1 line-2 line-3" || len(keys) != 3 {
+		t.Fatalf("first transcription rendered %v with keys %v, want three lines", got, keys)
+	}
+	if strings.Count(events, "mergeMode append") != 3 {
+		t.Errorf("new lines were not appended:\n%s", events)
+	}
+
+	events, keys2 := post("a94\no", keys)
+	if got := lines(events); strings.Join(got, " ") != "line-2" {
+		t.Errorf("editing line 2 rendered %v, want only line-2", got)
+	}
+	if strings.Contains(events, "mergeMode append") || !strings.Contains(events, "selector #line-3") {
+		t.Errorf("editing line 2 did not replace it in place and remove line 3:\n%s", events)
+	}
+	if len(keys2) != 2 || keys2[0] != keys[0] || keys2[1] == keys[1] {
+		t.Errorf("keys after editing line 2 = %v, were %v", keys2, keys)
+	}
+
+	if events, _ := post("", keys2); !strings.Contains(events, "selector #line-1") || lines(events) != nil {
+		t.Errorf("clearing the input did not remove every line:\n%s", events)
+	}
+}
+
+func TestAPITranscribeExplainSize(t *testing.T) {
+	for _, tt := range []struct {
+		size   int
+		status int
+	}{
+		{MaxExplainSize, http.StatusOK},
+		{MaxExplainSize + 1, http.StatusRequestEntityTooLarge},
+	} {
+		body, _ := json.Marshal(dataSignal{Data: strings.Repeat("a", tt.size)})
+		r := httptest.NewRequest(http.MethodPost, "/?explain=1", strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+		APITranscribe(w, r)
+		if w.Code != tt.status {
+			t.Errorf("explaining %d bytes: status %d, want %d", tt.size, w.Code, tt.status)
+		}
+	}
+}
+
+func TestAPITranscribeFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		accept  string
+		data    dataSignal
+		json    bool
+		explain bool
+	}{
+		{name: "text by default", target: "/", data: dataSignal{Data: "a94 Q(R)"}},
+		{name: "Accept", target: "/", accept: "application/json", data: dataSignal{Data: "a94 Q(R)"}, json: true},
+		{name: "format", target: "/", data: dataSignal{Data: "a94 Q(R)", Format: "json"}, json: true},
+		{name: "explain", target: "/?explain=1", data: dataSignal{Data: "a94 Q(R)"}, json: true, explain: true},
+		{name: "explain with Accept text", target: "/?explain=1", accept: "text/plain", data: dataSignal{Data: "a94 Q(R)"}, json: true, explain: true},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(tt.data)
+		r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(string(body)))
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		APITranscribe(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status %d, want 200: %s", tt.name, w.Code, w.Body)
+			continue
+		}
+
+		if !tt.json {
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+				t.Errorf("%s: Content-Type %q, want text/plain", tt.name, ct)
+			}
+			if got, want := w.Body.String(), "a\u0306 rare"; got != want {
+				t.Errorf("%s: body %q, want %q", tt.name, got, want)
+			}
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type %q, want application/json", tt.name, ct)
+		}
+		var res Result
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Errorf("%s: %v in %s", tt.name, err, w.Body)
+			continue
+		}
+		if res.Input != tt.data.Data || res.IPA != "a\u0306" || res.KeyVersion == "" {
+			t.Errorf("%s: input %q, IPA %q, key version %q; want %q, %q and a version", tt.name, res.Input, res.IPA, res.KeyVersion, tt.data.Data, "a\u0306")
+		}
+		if len(res.Annotations) != 1 || res.Annotations[0].Code != "Q(R)" || res.Annotations[0].Gloss != "rare" {
+			t.Errorf("%s: annotations %+v, want Q(R) rare", tt.name, res.Annotations)
+		}
+		if got := len(res.Trace) > 0; got != tt.explain {
+			t.Errorf("%s: trace %+v, want one only when explaining", tt.name, res.Trace)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxBatchItems is the most items an APITranscribeBatch request may have.
+const maxBatchItems = 1000
+
+// batchItem is one element of an APITranscribeBatch request. ID is an
+// optional client identifier echoed back unchanged in the result.
+type batchItem struct {
+	ID   json.RawMessage `json:"id,omitempty"`
+	Data *string         `json:"data"`
+	// Alphabet and Normalize are as in an APITranscribe request.
+	Alphabet  string `json:"alphabet,omitempty"`
+	Normalize string `json:"normalize,omitempty"`
+}
+
+// batchResult is one element of an APITranscribeBatch response. Exactly one
+// of the embedded transcription and Error is set.
+type batchResult struct {
+	ID json.RawMessage `json:"id,omitempty"`
+	*Result
+	Error string `json:"error,omitempty"`
+}
+
+// transcribeBatch transcribes every item, keeping their order. An item that
+// cannot be decoded gets an error instead of failing the whole batch. It
+// gives up with the error of ctx once ctx is done.
+func transcribeBatch(ctx context.Context, items []json.RawMessage) ([]batchResult, error) {
+	results := make([]batchResult, len(items))
+	for i, raw := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		item := batchItem{}
+		err := json.Unmarshal(raw, &item)
+		// A field of the wrong type does not stop the other fields from
+		// being decoded, so the ID is echoed whenever it could be read.
+		results[i].ID = item.ID
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if item.Data == nil {
+			results[i].Error = `missing "data" field`
+			continue
+		}
+		opts, err := requestOptions(item.Alphabet, item.Normalize)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if results[i].Result, err = TranscribeContext(ctx, *item.Data, opts); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func APITranscribeBatch(w http.ResponseWriter, r *http.Request) {
+	var items []json.RawMessage
+	if !readJSON(w, r, &items) {
+		return
+	}
+	if len(items) > maxBatchItems {
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("batch has %d items, more than %d", len(items), maxBatchItems))
+		return
+	}
+	results, err := transcribeBatch(r.Context(), items)
+	if err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, fmt.Errorf("batch not finished: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPITranscribeBatch(t *testing.T) {
+	// result is the part of a batchResult the tests compare; ID is the raw
+	// JSON sent back.
+	type result struct {
+		ID    string
+		IPA   string
+		Error bool
+	}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   []result
+	}{
+		{
+			name:   "in order",
+			body:   `[{"data": "a94"}, {"data": "s+"}, {"data": "b"}]`,
+			status: http.StatusOK,
+			want:   []result{{IPA: "a\u0306"}, {IPA: "ʃ"}, {IPA: "b"}},
+		},
+		{
+			name:   "ids",
+			body:   `[{"id": 7, "data": "a"}, {"id": "x-1", "data": "b"}, {"id": 1.50, "data": "c"}, {"id": {"n": [1]}, "data": "d"}]`,
+			status: http.StatusOK,
+			want: []result{
+				{ID: `7`, IPA: "a"},
+				{ID: `"x-1"`, IPA: "b"},
+				{ID: `1.50`, IPA: "ts"},
+				// Other values are compacted.
+				{ID: `{"n":[1]}`, IPA: "d"},
+			},
+		},
+		{
+			name:   "invalid items",
+			body:   `[{"id": 1, "data": "a"}, {"id": 2}, "a", {"id": 4, "data": 5}, {"id": 5, "data": "b", "alphabet": "klingon"}, {"id": 6, "data": "e"}]`,
+			status: http.StatusOK,
+			want: []result{
+				{ID: `1`, IPA: "a"},
+				{ID: `2`, Error: true},
+				{Error: true},
+				{ID: `4`, Error: true},
+				{ID: `5`, Error: true},
+				{ID: `6`, IPA: "e"},
+			},
+		},
+		{name: "empty", body: `[]`, status: http.StatusOK, want: []result{}},
+		{name: "most items", body: "[" + strings.Repeat(`{"data": "a"},`, maxBatchItems-1) + `{"data": "a"}]`, status: http.StatusOK},
+		{name: "too many items", body: "[" + strings.Repeat(`{"data": "a"},`, maxBatchItems) + `{"data": "a"}]`, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		APITranscribeBatch(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+			continue
+		}
+		if tt.want == nil {
+			continue
+		}
+
+		var results []struct {
+			ID    json.RawMessage `json:"id"`
+			IPA   string          `json:"ipa"`
+			Error string          `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+			t.Errorf("%s: %v in %s", tt.name, err, w.Body)
+			continue
+		}
+		if results == nil || len(results) != len(tt.want) {
+			t.Errorf("%s: %d results, want %d: %s", tt.name, len(results), len(tt.want), w.Body)
+			continue
+		}
+		for i, res := range results {
+			got := result{ID: string(res.ID), IPA: res.IPA, Error: res.Error != ""}
+			if got != tt.want[i] {
+				t.Errorf("%s: result %d = %+v, want %+v", tt.name, i, got, tt.want[i])
+			}
+		}
+	}
+}
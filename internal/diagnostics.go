@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity grades a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError marks input that was certainly mistyped, such as a code
+	// that does not exist.
+	SeverityError Severity = "error"
+	// SeverityWarning marks input that was passed through without being
+	// understood.
+	SeverityWarning Severity = "warning"
+	// SeverityInfo marks input that was understood but may not have been
+	// meant that way.
+	SeverityInfo Severity = "info"
+)
+
+// Diagnostic points at a span of the input that Transcribe did not fully
+// understand.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	// Offset and Len are the byte span of the problem in the input.
+	Offset int `json:"offset"`
+	Len    int `json:"len"`
+	// RuneOffset and RuneLen are the same span counted in runes.
+	RuneOffset int    `json:"runeOffset"`
+	RuneLen    int    `json:"runeLen"`
+	Message    string `json:"message"`
+}
+
+// diagnose reports codes in the input s that were not recognized or did not
+// apply to their segment, and the replacements of invalid UTF-8 at the spans
+// invalid, sorted by offset.
+func diagnose(s string, nodes []Node, invalid []Span) []Diagnostic {
+	diags := []Diagnostic{}
+	add := func(sev Severity, pos, n int, format string, args ...any) {
+		diags = append(diags, Diagnostic{
+			Severity: sev,
+			Offset:   pos,
+			Len:      n,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, sp := range invalid {
+		add(SeverityError, sp.Pos, sp.Len, "invalid UTF-8 replaced by %q", utf8.RuneError)
+	}
+
+	// Runs of stray digits or capitals are reported as a whole.
+	runPos, runLen := 0, 0
+	flush := func() {
+		if runLen == 0 {
+			return
+		}
+		run := s[runPos : runPos+runLen]
+		switch {
+		case run == notationTerminator:
+			add(SeverityWarning, runPos, runLen, "%s does not end any notation", run)
+		case isDigits(run):
+			add(SeverityWarning, runPos, runLen, "stray digits %q are not a code here", run)
+		default:
+			add(SeverityWarning, runPos, runLen, "unrecognized code %q", run)
+		}
+		runLen = 0
+	}
+
+	for _, n := range nodes {
+		if t, ok := n.(*Text); ok && !t.Unknown && len(t.Source) == 1 && isCodeChar(t.Source[0]) {
+			if runLen == 0 || runPos+runLen != t.Pos {
+				flush()
+				runPos = t.Pos
+			}
+			runLen += len(t.Source)
+			continue
+		}
+		flush()
+
+		switch n := n.(type) {
+		case *Text:
+			if n.Unknown {
+				add(SeverityError, n.Pos, len(n.Source), "unknown notation code %s", n.Source)
+			}
+		case *Segment:
+			for _, m := range n.Mods {
+				if !m.Applied && n.StressAt.Len != 0 && m.Pos > n.StressAt.Pos && isStressCode(m.Code) {
+					at := n.StressAt
+					add(SeverityWarning, m.Pos, len(m.Code), "extra stress %s after %s is ignored", m.Code, s[at.Pos:at.Pos+at.Len])
+				} else if !m.Applied {
+					add(SeverityError, m.Pos, len(m.Code), "code %s does not apply to %s", m.Code, n.Symbol)
+				}
+			}
+		case *Notation:
+			if n.Unterminated {
+				add(SeverityInfo, n.Pos, n.Len, "text of %s is not ended by %s", n.Code, notationTerminator)
+			}
+		}
+	}
+	flush()
+
+	slices.SortStableFunc(diags, func(a, b Diagnostic) int {
+		return a.Offset - b.Offset
+	})
+	// Rune offsets are counted in one pass over the edges of all spans, as
+	// counting each span on its own is quadratic on long lines.
+	edges := make([]int, 0, 2*len(diags))
+	for _, d := range diags {
+		edges = append(edges, d.Offset, d.Offset+d.Len)
+	}
+	slices.Sort(edges)
+	edges = slices.Compact(edges)
+	runesAt := make([]int, len(edges))
+	pos, runes := 0, 0
+	for i, e := range edges {
+		runes += utf8.RuneCountInString(s[pos:e])
+		pos = e
+		runesAt[i] = runes
+	}
+	runeOffset := func(off int) int {
+		i, _ := slices.BinarySearch(edges, off)
+		return runesAt[i]
+	}
+	for i := range diags {
+		d := &diags[i]
+		d.RuneOffset = runeOffset(d.Offset)
+		d.RuneLen = runeOffset(d.Offset+d.Len) - d.RuneOffset
+	}
+	return diags
+}
+
+// isCodeChar reports whether c can be part of a code: a digit or a capital.
+func isCodeChar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z'
+}
+
+// replaceInvalid replaces each run of bytes of s that are not valid UTF-8 by
+// U+FFFD. It returns the result and the spans of the replacements in it.
+func replaceInvalid(s string) (string, []Span) {
+	var b strings.Builder
+	var spans []Span
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r != utf8.RuneError || n != 1 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+		spans = append(spans, Span{b.Len(), utf8.RuneLen(utf8.RuneError)})
+		b.WriteRune(utf8.RuneError)
+		for i < len(s) {
+			if r, n := utf8.DecodeRuneInString(s[i:]); r != utf8.RuneError || n != 1 {
+				break
+			}
+			i++
+		}
+	}
+	return b.String(), spans
+}
+
+func isStressCode(code string) bool {
+	return code == "," || code == ",,"
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package internal
+
+import "testing"
+
+func TestDiagnostics(t *testing.T) {
+	type diag struct {
+		sev        Severity
+		off, runes int
+	}
+	tests := map[string][]diag{
+		"a94b s+":      nil,
+		"a2":           {{SeverityError, 1, 1}},
+		"ə2":           {{SeverityError, 2, 1}},
+		"Q(XYZ) a":     {{SeverityError, 0, 0}},
+		"a 9 b":        {{SeverityWarning, 2, 2}},
+		"b,, QP":       {{SeverityError, 1, 1}, {SeverityWarning, 4, 4}},
+		"Q(ED) x":      {{SeverityInfo, 0, 0}},
+		"a,,,":         {{SeverityWarning, 3, 3}},
+		"ə,,,, b":      {{SeverityWarning, 4, 3}},
+		"Q(ED) x QP ə": nil,
+		"ə \xff\xfe b": {{SeverityError, 3, 2}},
+	}
+	for in, want := range tests {
+		got := Transcribe(in, Options{}).Diagnostics
+		if len(got) != len(want) {
+			t.Errorf("Transcribe(%q).Diagnostics = %+v, want %d diagnostics", in, got, len(want))
+			continue
+		}
+		for i, d := range got {
+			if d.Severity != want[i].sev || d.Offset != want[i].off || d.RuneOffset != want[i].runes {
+				t.Errorf("Transcribe(%q).Diagnostics[%d] = %+v, want %+v", in, i, d, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// Bounds on how much longer than its input a rendering may be. Glosses and
+// the macros of some alphabets are long, but never more than this per byte
+// of input.
+const (
+	maxPhoneticGrowth = 4
+	maxOutputGrowth   = 32
+	maxInlineGrowth   = 128
+)
+
+func FuzzTranscribe(f *testing.F) {
+	for _, in := range keyInputs() {
+		f.Add(in)
+	}
+	f.Add("Q(ED) Q(I) Q(")
+	f.Add("a94+,,95.,")
+	f.Add("\xff\xfeQ(ED)\x80")
+
+	f.Fuzz(func(t *testing.T, in string) {
+		for _, s := range Transcribe(in, Options{Explain: true}).Trace {
+			if !utf8.ValidString(s.Replacement) || !utf8.ValidString(s.Output) {
+				t.Fatalf("Transcribe(%q) trace gave invalid UTF-8 %+v", in, s)
+			}
+		}
+		for _, n := range []Normalization{"", NFC, NFD} {
+			for _, a := range alphabets {
+				res := Transcribe(in, Options{Alphabet: a, Normalization: n})
+				inline := res.Inline()
+				for _, s := range []string{res.IPA, res.Output, inline} {
+					if !utf8.ValidString(s) {
+						t.Fatalf("Transcribe(%q) in %s gave invalid UTF-8 %q", in, a.Name(), s)
+					}
+				}
+				if n.Apply(res.IPA) != res.IPA || n.Apply(inline) != inline {
+					t.Fatalf("Transcribe(%q) in %q is not normalized: %q", in, n, inline)
+				}
+				if len(res.IPA) > maxPhoneticGrowth*len(in) {
+					t.Fatalf("Transcribe(%q) = %q, more than %d times as long", in, res.IPA, maxPhoneticGrowth)
+				}
+				if len(res.Output) > maxOutputGrowth*len(in) {
+					t.Fatalf("Transcribe(%q) in %s = %q, more than %d times as long", in, a.Name(), res.Output, maxOutputGrowth)
+				}
+				if len(inline) > maxInlineGrowth*len(in) {
+					t.Fatalf("Transcribe(%q).Inline() = %q, more than %d times as long", in, inline, maxInlineGrowth)
+				}
+			}
+		}
+	})
+}
+
+func FuzzUntranscribe(f *testing.F) {
+	for _, in := range keyInputs() {
+		f.Add(Transcribe(in, Options{}).IPA)
+		f.Add(Transcribe(in, Options{Normalization: NFC}).IPA)
+	}
+
+	f.Fuzz(func(t *testing.T, ipa string) {
+		typed := Untranscribe(ipa)
+		if utf8.ValidString(ipa) && !utf8.ValidString(typed) {
+			t.Fatalf("Untranscribe(%q) gave invalid UTF-8 %q", ipa, typed)
+		}
+		if len(typed) > maxPhoneticGrowth*len(ipa) {
+			t.Fatalf("Untranscribe(%q) = %q, more than %d times as long", ipa, typed, maxPhoneticGrowth)
+		}
+		// Once text that reads as notations is gone, untranscribing and
+		// transcribing again gives back the same IPA, up to canonical
+		// equivalence: marks typed as text are not put in key order, and
+		// decomposing a character may reveal a key symbol. The IPA may be in
+		// any normalization form.
+		once := Transcribe(Untranscribe(ipa), Options{Normalization: NFD})
+		twice := Transcribe(Untranscribe(once.IPA), Options{Normalization: NFD})
+		if len(once.Annotations) > 0 || len(twice.Annotations) > 0 {
+			return
+		}
+		if once.IPA != twice.IPA {
+			t.Fatalf("Untranscribe(%q) does not settle: IPA %q, then %q", ipa, once.IPA, twice.IPA)
+		}
+	})
+}
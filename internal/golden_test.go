@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden is the expected transcription of one corpus line.
+type golden struct {
+	Input  string `json:"input"`
+	IPA    string `json:"ipa"`
+	Inline string `json:"inline"`
+	// Glosses are those of the notations, in input order.
+	Glosses []string `json:"glosses,omitempty"`
+}
+
+// readCorpus returns the inputs in the corpus file name.
+func readCorpus(t *testing.T, name string) []string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inputs []string
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		inputs = append(inputs, line)
+	}
+	return inputs
+}
+
+// TestGolden transcribes the corpus in testdata and compares the results to
+// those recorded in its golden file, so that a change to the key or the
+// transcriber cannot silently change earlier transcriptions.
+func TestGolden(t *testing.T) {
+	inputs := readCorpus(t, filepath.Join("testdata", "corpus.txt"))
+	goldenPath := filepath.Join("testdata", "corpus.golden")
+
+	var got []golden
+	for _, in := range inputs {
+		res := Transcribe(in, Options{})
+		g := golden{Input: in, IPA: res.IPA, Inline: res.Inline()}
+		for _, a := range res.Annotations {
+			g.Glosses = append(g.Glosses, a.Gloss)
+		}
+		got = append(got, g)
+	}
+
+	if *update {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		for _, g := range got {
+			if err := enc.Encode(g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(goldenPath, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := map[string]golden{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var g golden
+		if err := json.Unmarshal(sc.Bytes(), &g); err != nil {
+			t.Fatalf("%s: %v", goldenPath, err)
+		}
+		want[g.Input] = g
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, g := range got {
+		w, ok := want[g.Input]
+		switch {
+		case !ok:
+			t.Errorf("%q has no golden result; run with -update", g.Input)
+		case g.IPA != w.IPA:
+			t.Errorf("Transcribe(%q).IPA = %q, golden %q", g.Input, g.IPA, w.IPA)
+		case g.Inline != w.Inline:
+			t.Errorf("Transcribe(%q).Inline() = %q, golden %q", g.Input, g.Inline, w.Inline)
+		case !slices.Equal(g.Glosses, w.Glosses):
+			t.Errorf("Transcribe(%q) glosses = %q, golden %q", g.Input, g.Glosses, w.Glosses)
+		}
+	}
+	if len(want) != len(got) {
+		t.Errorf("golden file has %d results for %d inputs; run with -update", len(want), len(got))
+	}
+}
+
+// TestCorpusCoverage checks that the corpus exercises every entry of the key.
+func TestCorpusCoverage(t *testing.T) {
+	applied := map[string]bool{}
+	for _, in := range readCorpus(t, filepath.Join("testdata", "corpus.txt")) {
+		for _, s := range Transcribe(in, Options{Explain: true}).Trace {
+			applied[s.Rule] = true
+		}
+	}
+	var rules []string
+	for _, k := range key.vowelKeys {
+		rules = append(rules, diacriticRule(SegmentVowel, k))
+	}
+	for _, k := range key.consKeys {
+		rules = append(rules, diacriticRule(SegmentConsonant, k))
+	}
+	for _, n := range key.notKeys {
+		rules = append(rules, "notation:"+n.code)
+	}
+	for _, r := range rules {
+		if !applied[r] {
+			t.Errorf("no corpus line applies %s", r)
+		}
+	}
+}
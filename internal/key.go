@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+//go:embed keys/lcaaj.json
+var defaultKeyFile []byte
+
+// key is the transcription key in use. It is replaced by SetKey at startup
+// and must not be changed while transcriptions are running.
+var key = mustParseKey(defaultKeyFile)
+
+// Key is a validated and compiled transcription key.
+type Key struct {
+	// Version identifies the revision of the key. It is reported alongside
+	// transcriptions so that output can be traced back to the rules that
+	// produced it.
+	Version string
+
+	// basicRules map symbols that are typed in place of an IPA base symbol
+	// to that symbol.
+	basicRules []symbolRule
+	// baseRules map key symbols whose IPA rendering differs from the symbol
+	// itself.
+	baseRules        []symbolRule
+	vowelSymbols     string
+	consonantSymbols string
+	// breakCode marks a syllable break after a vowel and a glottal stop
+	// elsewhere.
+	breakCode string
+	vowelKeys []diacriticKey
+	consKeys  []diacriticKey
+	notKeys   []notation
+	// diacriticCodes lists the codes that modify a preceding segment,
+	// longest first so that e.g. 94 is not read as 9 followed by 4.
+	diacriticCodes []string
+	// untypedBases lists the IPA base symbols produced by baseRules and
+	// consonant keys, longest first, for Untranscribe.
+	untypedBases []untypedBase
+}
+
+// SetKey makes k the key used by all transcriptions.
+func SetKey(k *Key) {
+	key = k
+}
+
+// LoadKey reads and compiles the key file at path.
+func LoadKey(path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+func mustParseKey(b []byte) *Key {
+	k, err := ParseKey(b)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// keyFile is the JSON form of a key; see keys/lcaaj.json.
+type keyFile struct {
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Segments    struct {
+		Vowels     string     `json:"vowels"`
+		Consonants string     `json:"consonants"`
+		Break      string     `json:"break"`
+		Typed      []ruleFile `json:"typed"`
+		IPA        []ruleFile `json:"ipa"`
+	} `json:"segments"`
+	Diacritics struct {
+		Vowel     []diacriticFile `json:"vowel"`
+		Consonant []diacriticFile `json:"consonant"`
+	} `json:"diacritics"`
+	Notations []notationFile `json:"notations"`
+}
+
+type ruleFile struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type diacriticFile struct {
+	Name    string     `json:"name"`
+	Code    string     `json:"code"`
+	Accepts string     `json:"accepts"`
+	Mark    string     `json:"mark"`
+	Replace []ruleFile `json:"replace"`
+}
+
+type notationFile struct {
+	Code    string `json:"code"`
+	Gloss   string `json:"gloss"`
+	Arg     string `json:"arg"`
+	Bounded bool   `json:"bounded"`
+}
+
+// ParseKey validates and compiles a key file. All problems found are
+// reported together.
+func ParseKey(b []byte) (*Key, error) {
+	f := &keyFile{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	check(f.Version != "", "missing version")
+	check(f.Segments.Vowels != "", "no vowels")
+	check(f.Segments.Consonants != "", "no consonants")
+	check(f.Segments.Break != "", "missing break code")
+
+	k := &Key{
+		Version:          f.Version,
+		vowelSymbols:     f.Segments.Vowels,
+		consonantSymbols: f.Segments.Consonants,
+		breakCode:        f.Segments.Break,
+		basicRules:       compileRules(f.Segments.Typed, "typed", check),
+		baseRules:        compileRules(f.Segments.IPA, "ipa", check),
+		vowelKeys:        compileDiacritics(f.Diacritics.Vowel, f.Segments.Vowels, "vowel", check),
+		consKeys:         compileDiacritics(f.Diacritics.Consonant, f.Segments.Consonants, "consonant", check),
+	}
+	for _, r := range k.basicRules {
+		check(strings.Contains(k.vowelSymbols+k.consonantSymbols, r.to), "typed %q: %q is not a vowel or consonant", r.from, r.to)
+	}
+
+	for i, n := range f.Notations {
+		var arg argKind
+		switch n.Arg {
+		case "":
+		case "text":
+			arg = argFree
+		case "word":
+			arg = argWord
+		default:
+			check(false, "notation %q: unknown arg %q", n.Code, n.Arg)
+		}
+		check(n.Code != "", "notation %d: missing code", i)
+		check(n.Gloss != "", "notation %q: missing gloss", n.Code)
+		check(strings.Contains(n.Gloss, "%s") == (arg != argNone), "notation %q: gloss must contain %%s exactly when the code takes an argument", n.Code)
+		check(strings.Count(n.Gloss, "%") == strings.Count(n.Gloss, "%s") && strings.Count(n.Gloss, "%s") <= 1, "notation %q: gloss may hold no %% but a single %%s", n.Code)
+		for _, earlier := range k.notKeys {
+			check(!strings.HasPrefix(n.Code, earlier.code) || !n.Bounded && earlier.bounded, "notation %q is shadowed by earlier notation %q", n.Code, earlier.code)
+		}
+		k.notKeys = append(k.notKeys, notation{code: n.Code, gloss: n.Gloss, arg: arg, bounded: n.Bounded})
+	}
+
+	k.diacriticCodes = []string{k.breakCode}
+	for _, d := range slices.Concat(k.vowelKeys, k.consKeys) {
+		if !slices.Contains(k.diacriticCodes, d.code) {
+			k.diacriticCodes = append(k.diacriticCodes, d.code)
+		}
+	}
+	slices.SortStableFunc(k.diacriticCodes, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+
+	for _, d := range k.consKeys {
+		for _, r := range d.replace {
+			k.untypedBases = append(k.untypedBases, untypedBase{ipa: r.to, symbol: r.from, code: d.code})
+		}
+	}
+	for _, r := range k.baseRules {
+		k.untypedBases = append(k.untypedBases, untypedBase{ipa: r.to, symbol: r.from})
+	}
+	slices.SortStableFunc(k.untypedBases, func(a, b untypedBase) int {
+		return cmp.Compare(len(b.ipa), len(a.ipa))
+	})
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return k, nil
+}
+
+func compileRules(rules []ruleFile, what string, check func(bool, string, ...any)) []symbolRule {
+	var compiled []symbolRule
+	for _, r := range rules {
+		check(r.From != "" && r.To != "", "%s rule %q -> %q: empty symbol", what, r.From, r.To)
+		for _, earlier := range compiled {
+			check(!strings.HasPrefix(r.From, earlier.from), "%s rule %q is shadowed by earlier rule %q", what, r.From, earlier.from)
+		}
+		compiled = append(compiled, symbolRule{r.From, r.To})
+	}
+	return compiled
+}
+
+func compileDiacritics(keys []diacriticFile, symbols, what string, check func(bool, string, ...any)) []diacriticKey {
+	var compiled []diacriticKey
+	for _, d := range keys {
+		check(d.Code != "", "%s diacritic %q: missing code", what, d.Name)
+		check((d.Mark == "") != (d.Replace == nil), "%s diacritic %q: exactly one of mark and replace must be set", what, d.Name)
+		for _, r := range d.Accepts {
+			check(strings.ContainsRune(symbols, r), "%s diacritic %q: %q is not a %s", what, d.Name, r, what)
+		}
+		for _, r := range d.Replace {
+			check(strings.Contains(d.Accepts, r.From), "%s diacritic %q: replaces %q, which it does not accept", what, d.Name, r.From)
+		}
+		for _, earlier := range compiled {
+			if earlier.code != d.Code {
+				continue
+			}
+			for _, r := range d.Accepts {
+				check(!strings.ContainsRune(earlier.accepts, r), "%s diacritic %q on %q is shadowed by earlier diacritic %q", what, d.Name, r, earlier.name)
+			}
+		}
+		compiled = append(compiled, diacriticKey{
+			name:    d.Name,
+			code:    d.Code,
+			accepts: d.Accepts,
+			mark:    d.Mark,
+			replace: compileRules(d.Replace, what+" diacritic "+d.Name, check),
+		})
+	}
+	return compiled
+}
+
+// symbolRule rewrites the key symbol from as to. Rule lists are tried in
+// order and the first rule that matches wins, so a rule must come before any
+// rule whose from is a prefix of its own.
+type symbolRule struct {
+	from string
+	to   string
+}
+
+// matchRule returns the first rule in rules whose from is a prefix of s.
+func matchRule(rules []symbolRule, s string) (symbolRule, bool) {
+	for _, r := range rules {
+		if strings.HasPrefix(s, r.from) {
+			return r, true
+		}
+	}
+	return symbolRule{}, false
+}
+
+// lookupRule returns the to of the first rule in rules whose from is s.
+func lookupRule(rules []symbolRule, s string) (string, bool) {
+	for _, r := range rules {
+		if r.from == s {
+			return r.to, true
+		}
+	}
+	return "", false
+}
+
+const (
+	lengthMark    = "ː"
+	syllableBreak = "."
+	primaryMark   = "ˈ"
+	secondaryMark = "ˌ"
+	syllabicMark  = "\u0329"
+)
+
+// diacriticKey describes what a diacritic code does to the segments it
+// accepts. A key either appends mark to the base or, if replace is set,
+// swaps the base for another symbol. Keys are tried in order and the first
+// key with a matching code that accepts the segment wins.
+type diacriticKey struct {
+	name    string
+	code    string
+	accepts string
+	mark    string
+	replace []symbolRule
+}
+
+type argKind int
+
+const (
+	argNone argKind = iota
+	// argFree is free text running up to the notationTerminator; see
+	// lexFreeText.
+	argFree
+	// argWord is a run of letters and digits directly after the code.
+	argWord
+)
+
+const (
+	notationTerminator = "QP"
+	// notationPrefix starts every Q(...) code.
+	notationPrefix = "Q("
+)
+
+// notation is an editorial code from the key. If bounded is set the code is
+// only recognized at the start of the input or after a character that is
+// not a letter or digit, so that e.g. a nasalizing + is not read as "yes".
+// Like symbol rules, notations are tried in order and the first match wins.
+type notation struct {
+	code    string
+	gloss   string
+	arg     argKind
+	bounded bool
+}
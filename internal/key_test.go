@@ -0,0 +1,35 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	if _, err := ParseKey(defaultKeyFile); err != nil {
+		t.Fatalf("default key: %v", err)
+	}
+
+	tests := []struct {
+		old, new string
+		want     string
+	}{
+		{`"version": "1"`, `"version": ""`, "missing version"},
+		{`"version"`, `"versoin"`, "unknown field"},
+		{`{"code": "Q(ADJ)", "gloss": "adjective"}`, `{"code": "Q(A", "gloss": "a"}, {"code": "Q(ADJ)", "gloss": "adjective"}`, "is shadowed by"},
+		{`"arg": "word"`, `"arg": "words"`, "unknown arg"},
+		{`"gloss": "yes but: %s"`, `"gloss": "yes but (100%): %s"`, "no % but a single %s"},
+		{`"gloss": "yes but: %s"`, `"gloss": "yes but: %s, %s"`, "no % but a single %s"},
+		{`"code": "94", "accepts": "aeiouəɪʌ"`, `"code": "94", "accepts": "aeiouəɪʌk"`, "is not a vowel"},
+		{`"mark": "\u0325"`, `"mark": "\u0325", "replace": [{"from": "b", "to": "p"}]`, "exactly one of"},
+	}
+	for _, tt := range tests {
+		b := strings.Replace(string(defaultKeyFile), tt.old, tt.new, 1)
+		if b == string(defaultKeyFile) {
+			t.Fatalf("%q not found in default key", tt.old)
+		}
+		if _, err := ParseKey([]byte(b)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("replacing %q: ParseKey error = %v, want %q", tt.old, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the lexical class of a Token.
+type TokenKind int
+
+const (
+	// TokenSegment is a phonetic base symbol: a lowercase letter, one of the
+	// numeric symbols 3, 1, 6 and 95, or an IPA symbol of the key typed
+	// directly.
+	TokenSegment TokenKind = iota
+	// TokenDiacritic is a code such as 94, 2 or + modifying the segment
+	// before it.
+	TokenDiacritic
+	// TokenStress is a , or ,, following a segment.
+	TokenStress
+	// TokenLength is the length mark ".".
+	TokenLength
+	// TokenNotation is an editorial notation code and its argument, if any.
+	TokenNotation
+	// TokenText is anything else; it is passed through unchanged.
+	TokenText
+	// TokenUnknown is a Q(...) code that is not in the key. It is passed
+	// through unchanged.
+	TokenUnknown
+)
+
+// Token is a single lexeme of LCAAJ key notation.
+type Token struct {
+	Kind TokenKind
+	// Text is the source text of the token, including any argument.
+	Text string
+	// Pos is the byte offset of the token in the input.
+	Pos int
+	// Arg is the free-text argument of a notation.
+	Arg string
+	// Nested holds notations that appeared inside Arg.
+	Nested []Token
+	// Unterminated is set when the free text of a notation was not ended by
+	// the notationTerminator.
+	Unterminated bool
+
+	notation *notation
+}
+
+// Lex splits s into tokens. Whether a code such as + or 2 is read as a
+// diacritic or as something else depends only on whether it directly follows
+// a segment, so the result does not depend on any later rewriting.
+func Lex(s string) []Token {
+	toks, _ := lex(context.Background(), s)
+	return toks
+}
+
+// lex is Lex that gives up with the error of ctx once ctx is done.
+func lex(ctx context.Context, s string) ([]Token, error) {
+	var toks []Token
+	attached := false
+	for i := 0; i < len(s); {
+		if len(toks)%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		t := lexToken(s, i, attached)
+		toks = append(toks, t)
+		i += len(t.Text)
+
+		switch t.Kind {
+		case TokenSegment, TokenDiacritic, TokenStress:
+			attached = true
+		case TokenLength:
+		default:
+			attached = false
+		}
+	}
+	return toks, nil
+}
+
+func lexToken(s string, i int, attached bool) Token {
+	rest := s[i:]
+	if attached {
+		for _, c := range key.diacriticCodes {
+			if strings.HasPrefix(rest, c) {
+				return Token{Kind: TokenDiacritic, Text: c, Pos: i}
+			}
+		}
+		if strings.HasPrefix(rest, ",,") {
+			return Token{Kind: TokenStress, Text: ",,", Pos: i}
+		}
+		if rest[0] == ',' {
+			return Token{Kind: TokenStress, Text: ",", Pos: i}
+		}
+	}
+	if rest[0] == '.' {
+		return Token{Kind: TokenLength, Text: ".", Pos: i}
+	}
+	if t, ok := lexNotation(s, i); ok {
+		return t
+	}
+	if strings.HasPrefix(rest, notationPrefix) {
+		n := len(notationPrefix)
+		if j := strings.IndexByte(rest, ')'); j >= 0 {
+			n = j + 1
+		}
+		return Token{Kind: TokenUnknown, Text: rest[:n], Pos: i}
+	}
+	if r, ok := matchRule(key.basicRules, rest); ok {
+		return Token{Kind: TokenSegment, Text: r.from, Pos: i}
+	}
+
+	r, size := utf8.DecodeRuneInString(rest)
+	if isSegment(r) {
+		return Token{Kind: TokenSegment, Text: rest[:size], Pos: i}
+	}
+	return Token{Kind: TokenText, Text: rest[:size], Pos: i}
+}
+
+func lexNotation(s string, i int) (Token, bool) {
+	rest := s[i:]
+	for k := range key.notKeys {
+		n := &key.notKeys[k]
+		if !strings.HasPrefix(rest, n.code) {
+			continue
+		}
+		if n.bounded && i > 0 && isAlnum(s[i-1]) {
+			continue
+		}
+
+		end := i + len(n.code)
+		t := Token{Kind: TokenNotation, Pos: i, notation: n}
+		switch n.arg {
+		case argFree:
+			var terminated bool
+			t.Arg, t.Nested, end, terminated = lexFreeText(s, end)
+			t.Unterminated = !terminated
+		case argWord:
+			j := end
+			for j < len(s) && isAlnum(s[j]) {
+				j++
+			}
+			t.Arg = strings.TrimSpace(s[end:j])
+			end = j
+		}
+		t.Text = s[i:end]
+		return t, true
+	}
+	return Token{}, false
+}
+
+// lexFreeText reads the free-text argument of a notation starting at byte i
+// of s. The text runs up to the notationTerminator, which is consumed, or up
+// to the next Q(...) code that takes no text, or to the end of s. A Q(...)
+// code that does take text is nested: it is lexed with its own argument and
+// returned separately, and the outer text continues after it. It returns the
+// text with whitespace collapsed, the nested notations, the offset just past
+// the argument and whether the text was ended by the terminator.
+func lexFreeText(s string, i int) (string, []Token, int, bool) {
+	var text strings.Builder
+	var nested []Token
+	j := i
+	for j < len(s) {
+		rest := s[j:]
+		if strings.HasPrefix(rest, notationTerminator) {
+			text.WriteString(s[i:j])
+			return strings.Join(strings.Fields(text.String()), " "), nested, j + len(notationTerminator), true
+		}
+		if strings.HasPrefix(rest, notationPrefix) {
+			if t, ok := lexNotation(s, j); ok {
+				if t.notation.arg != argFree {
+					break
+				}
+				text.WriteString(s[i:j])
+				text.WriteByte(' ')
+				nested = append(nested, t)
+				j += len(t.Text)
+				i = j
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		j += size
+	}
+	text.WriteString(s[i:j])
+	return strings.Join(strings.Fields(text.String()), " "), nested, j, false
+}
+
+func isSegment(r rune) bool {
+	if r >= 'a' && r <= 'z' {
+		return true
+	}
+	return strings.ContainsRune(key.vowelSymbols+key.consonantSymbols, r)
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
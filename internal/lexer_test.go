@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	// tok is the part of a Token the tests compare.
+	type tok struct {
+		kind TokenKind
+		text string
+		pos  int
+	}
+	tests := []struct {
+		in   string
+		want []tok
+	}{
+		{"a94,, s+", []tok{
+			{TokenSegment, "a", 0}, {TokenDiacritic, "94", 1}, {TokenStress, ",,", 3},
+			{TokenText, " ", 5}, {TokenSegment, "s", 6}, {TokenDiacritic, "+", 7},
+		}},
+		{"3k1", []tok{{TokenSegment, "3", 0}, {TokenSegment, "k", 1}, {TokenSegment, "1", 2}}},
+		{"95", []tok{{TokenSegment, "95", 0}}},
+		{"k95", []tok{{TokenSegment, "k", 0}, {TokenDiacritic, "95", 1}}},
+		{"a.", []tok{{TokenSegment, "a", 0}, {TokenLength, ".", 1}}},
+		{"a.,", []tok{{TokenSegment, "a", 0}, {TokenLength, ".", 1}, {TokenStress, ",", 2}}},
+		{". a", []tok{{TokenLength, ".", 0}, {TokenText, " ", 1}, {TokenSegment, "a", 2}}},
+
+		// , + and - are modifiers only when attached to a segment.
+		{"a+", []tok{{TokenSegment, "a", 0}, {TokenDiacritic, "+", 1}}},
+		{"a +", []tok{{TokenSegment, "a", 0}, {TokenText, " ", 1}, {TokenNotation, "+", 2}}},
+		{"+ a", []tok{{TokenNotation, "+", 0}, {TokenText, " ", 1}, {TokenSegment, "a", 2}}},
+		{"b,", []tok{{TokenSegment, "b", 0}, {TokenStress, ",", 1}}},
+		{"a, b", []tok{{TokenSegment, "a", 0}, {TokenStress, ",", 1}, {TokenText, " ", 2}, {TokenSegment, "b", 3}}},
+		{", a", []tok{{TokenText, ",", 0}, {TokenText, " ", 1}, {TokenSegment, "a", 2}}},
+		{"a -", []tok{{TokenSegment, "a", 0}, {TokenText, " ", 1}, {TokenNotation, "-", 2}}},
+		{"a-", []tok{{TokenSegment, "a", 0}, {TokenText, "-", 1}}},
+
+		{"Q(R)b", []tok{{TokenNotation, "Q(R)", 0}, {TokenSegment, "b", 4}}},
+		{"Q(ED) x QP", []tok{{TokenNotation, "Q(ED) x QP", 0}}},
+
+		// Q( codes not in the key are passed through, up to the ) if any.
+		{"Q(XYZ) a", []tok{{TokenUnknown, "Q(XYZ)", 0}, {TokenText, " ", 6}, {TokenSegment, "a", 7}}},
+		{"Q(ED", []tok{{TokenUnknown, "Q(", 0}, {TokenText, "E", 2}, {TokenText, "D", 3}}},
+	}
+	for _, tt := range tests {
+		var got []tok
+		for _, t := range Lex(tt.in) {
+			got = append(got, tok{t.Kind, t.Text, t.Pos})
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Lex(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLexFreeText(t *testing.T) {
+	tests := []struct {
+		in           string
+		text         string
+		arg          string
+		nested       int
+		unterminated bool
+	}{
+		{"Q(ED)  two   words QP", "Q(ED)  two   words QP", "two words", 0, false},
+		{"Q(ED) x Q(R)", "Q(ED) x ", "x", 0, true},
+		{"Q(ED) x Q(S) y QP z QP", "Q(ED) x Q(S) y QP z QP", "x z", 1, false},
+		{"Q(ED) x", "Q(ED) x", "x", 0, true},
+	}
+	for _, tt := range tests {
+		toks := Lex(tt.in)
+		if len(toks) == 0 || toks[0].Kind != TokenNotation {
+			t.Errorf("Lex(%q) = %+v, want a notation first", tt.in, toks)
+			continue
+		}
+		got := toks[0]
+		if got.Text != tt.text || got.Arg != tt.arg || len(got.Nested) != tt.nested || got.Unterminated != tt.unterminated {
+			t.Errorf("Lex(%q)[0] = text %q, arg %q, %d nested, unterminated %v; want %q, %q, %d, %v",
+				tt.in, got.Text, got.Arg, len(got.Nested), got.Unterminated, tt.text, tt.arg, tt.nested, tt.unterminated)
+		}
+	}
+}
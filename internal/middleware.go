@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// MaxBodySize is the largest request body accepted, in bytes. It matches the
+// longest line the command line tool reads.
+const MaxBodySize = 1 << 20
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+	// RequestID identifies the request in the server log.
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// writeError responds to r with status and a JSON apiError for err.
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Error: err.Error(), RequestID: middleware.GetReqID(r.Context())})
+}
+
+// writeJSON responds with status and v as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readJSON decodes the body of r, which must be a single JSON value no longer
+// than MaxBodySize, into v. Fields v does not have are an error. If it fails
+// it responds with an error and returns false.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	b, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("request body is larger than %d bytes", MaxBodySize))
+			return false
+		}
+		writeError(w, r, http.StatusBadRequest, err)
+		return false
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		writeError(w, r, http.StatusBadRequest, errors.New("request body is empty"))
+		return false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+		return false
+	}
+	if dec.More() {
+		writeError(w, r, http.StatusBadRequest, errors.New("invalid JSON: more than one value in request body"))
+		return false
+	}
+	return true
+}
+
+// Recoverer turns a panic in a handler into a 500 response, logging it with
+// the request ID and stack, so that one bad request cannot take the server
+// down for everyone.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			log.Printf("panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, middleware.GetReqID(r.Context()), p, debug.Stack())
+			writeError(w, r, http.StatusInternalServerError, errors.New("internal server error"))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Timeout cancels the context of a request after d. A handler that gives up
+// because of that without responding gets a 503 error.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			if ctx.Err() == context.DeadlineExceeded && ww.Status() == 0 {
+				writeError(ww, r, http.StatusServiceUnavailable, errors.New("request timed out"))
+			}
+		})
+	}
+}
+
+// NotFound responds with a 404 error.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, fmt.Errorf("no such page: %s", r.URL.Path))
+}
+
+// MethodNotAllowed responds with a 405 error.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed on %s", r.Method, r.URL.Path))
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    string
+		status  int
+	}{
+		{"valid", APITranscribe, `{"data": "a94"}`, http.StatusOK},
+		{"empty", APITranscribe, ``, http.StatusBadRequest},
+		{"syntax", APITranscribe, `{"data": "a94"`, http.StatusBadRequest},
+		{"type", APITranscribe, `{"data": 5}`, http.StatusBadRequest},
+		{"unknown field", APITranscribe, `{"data": "a", "dta": "b"}`, http.StatusBadRequest},
+		{"trailing", APITranscribe, `{"data": "a"} {}`, http.StatusBadRequest},
+		{"alphabet", APITranscribe, `{"data": "a", "alphabet": "klingon"}`, http.StatusBadRequest},
+		{"too large", APITranscribe, `{"data": "` + strings.Repeat("a", MaxBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+		{"batch", APITranscribeBatch, `[{"data": "a"}]`, http.StatusOK},
+		{"batch object", APITranscribeBatch, `{"data": "a"}`, http.StatusBadRequest},
+		{"batch too long", APITranscribeBatch, "[" + strings.Repeat(`{},`, maxBatchItems) + "{}]", http.StatusRequestEntityTooLarge},
+		{"untranscribe", APIUntranscribe, `{"data": 1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		middleware.RequestID(tt.handler).ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+			continue
+		}
+		if tt.status == http.StatusOK {
+			continue
+		}
+		var e apiError
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Error == "" || e.RequestID == "" {
+			t.Errorf("%s: error body %q is not an error with a request ID", tt.name, w.Body)
+		}
+	}
+}
+
+func TestRecoverer(t *testing.T) {
+	h := Recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"error"`) {
+		t.Errorf("panicking handler gave %d %q, want a 500 error", w.Code, w.Body)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("slow handler gave %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+// TestTimeoutTranscription checks that a transcription too long to finish
+// in time is given up rather than finished after the deadline.
+func TestTimeoutTranscription(t *testing.T) {
+	body, _ := json.Marshal(dataSignal{Data: strings.Repeat("a94,, s+ ", MaxBodySize/10)})
+	h := Timeout(time.Millisecond)(http.HandlerFunc(APITranscribe))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow transcription gave %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	var e apiError
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || !strings.Contains(e.Error, "not finished") {
+		t.Errorf("slow transcription gave %s, want an error saying it stopped", w.Body)
+	}
+}
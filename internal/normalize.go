@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization is a Unicode normalization form for transcriptions. It is
+// one of three values:
+//
+//   - "", the zero value, leaves output as rendered. A segment is written as
+//     its base followed by each mark as a separate code point, in the
+//     renderer's canonical order: combining marks, then spacing ones such as
+//     ʲ, each group in the order of the key. This is neither NFC nor NFD in
+//     general, and text passed through from the input is left as typed.
+//   - NFC gives the whole output in Unicode Normalization Form C.
+//   - NFD gives the whole output in Unicode Normalization Form D.
+type Normalization string
+
+const (
+	// NFC composes marks with their base where Unicode has a precomposed
+	// character, as in ã, and orders the marks left over by their
+	// combining class.
+	NFC Normalization = "nfc"
+	// NFD decomposes every precomposed character, including any typed in
+	// the input, and orders the marks of each base by their combining
+	// class, which may differ from the renderer's order.
+	NFD Normalization = "nfd"
+)
+
+// ParseNormalization returns the normalization called name, in either case.
+// An empty name is no normalization.
+func ParseNormalization(name string) (Normalization, error) {
+	switch n := Normalization(strings.ToLower(name)); n {
+	case "", NFC, NFD:
+		return n, nil
+	}
+	return "", fmt.Errorf("unknown normalization %q", name)
+}
+
+func (n Normalization) form() (norm.Form, bool) {
+	switch n {
+	case NFC:
+		return norm.NFC, true
+	case NFD:
+		return norm.NFD, true
+	}
+	return 0, false
+}
+
+// Apply normalizes s.
+func (n Normalization) Apply(s string) string {
+	if f, ok := n.form(); ok {
+		return f.String(s)
+	}
+	return s
+}
+
+// align normalizes s and maps its alignment to the normalized string. Runs
+// of s that normalization combines into one character, like a vowel and a
+// mark from a later code, become one run spanning the input of both.
+func (n Normalization) align(s string, align []Alignment) (string, []Alignment) {
+	f, ok := n.form()
+	if !ok {
+		return s, align
+	}
+
+	var b strings.Builder
+	var out []Alignment
+	var it norm.Iter
+	it.InitString(f, s)
+	// lo is the rune offset in s of the next segment to normalize, outLen
+	// the length in runes of the output so far and next the first run that
+	// may overlap that segment.
+	lo, outLen, next := 0, 0, 0
+	for !it.Done() {
+		start := it.Pos()
+		seg := it.Next()
+		hi := lo + utf8.RuneCountInString(s[start:it.Pos()])
+
+		for next < len(align) && align[next].Out+align[next].OutLen <= lo {
+			next++
+		}
+		from := Span{Pos: -1}
+		for _, a := range align[next:] {
+			if a.Out >= hi {
+				break
+			}
+			if from.Pos < 0 {
+				from = Span{a.In, a.InLen}
+				continue
+			}
+			end := max(from.Pos+from.Len, a.In+a.InLen)
+			from.Pos = min(from.Pos, a.In)
+			from.Len = end - from.Pos
+		}
+		lo = hi
+
+		n := utf8.RuneCount(seg)
+		if n == 0 {
+			continue
+		}
+		b.Write(seg)
+		if last := len(out) - 1; last >= 0 && out[last].In == from.Pos && out[last].InLen == from.Len {
+			out[last].OutLen += n
+		} else {
+			out = append(out, Alignment{Out: outLen, OutLen: n, In: from.Pos, InLen: from.Len})
+		}
+		outLen += n
+	}
+	return b.String(), out
+}
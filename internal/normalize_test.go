@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestMarkOrder(t *testing.T) {
+	tests := [][]string{
+		{"a94+", "a+94"},
+		{"e4,,", "e,,4"},
+		{"b82", "b28"},
+		{"n8,", "n,8"},
+	}
+	for _, tt := range tests {
+		want := Transcribe(tt[0], Options{}).IPA
+		for _, in := range tt[1:] {
+			if got := Transcribe(in, Options{}).IPA; got != want {
+				t.Errorf("Transcribe(%q) = %q, want %q as for %q", in, got, want, tt[0])
+			}
+		}
+	}
+	if got, want := Transcribe("b82", Options{}).IPA, "b\u0325\u02b2"; got != want {
+		t.Errorf("Transcribe(%q) = %q, want the combining mark before the spacing one: %q", "b82", got, want)
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    Normalization
+		want string
+	}{
+		{"a+", "", "a\u0303"},
+		{"a+", NFC, "\u00e3"},
+		{"a+", NFD, "a\u0303"},
+		{"\u00e3", NFD, "a\u0303"},
+		{"a94+ b", NFC, "\u1eb5 b"},
+	}
+	for _, tt := range tests {
+		if got := Transcribe(tt.in, Options{Normalization: tt.n}).IPA; got != tt.want {
+			t.Errorf("Transcribe(%q) in %q = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+
+	forms := map[Normalization]norm.Form{NFC: norm.NFC, NFD: norm.NFD}
+	for _, n := range []Normalization{NFC, NFD} {
+		for _, in := range keyInputs() {
+			res := Transcribe(in, Options{Normalization: n})
+			if !forms[n].IsNormalString(res.IPA) || !forms[n].IsNormalString(res.Inline()) {
+				t.Errorf("Transcribe(%q) in %q = %q, inline %q, not normalized", in, n, res.IPA, res.Inline())
+			}
+			out := 0
+			for _, a := range res.Alignment {
+				if a.Out != out || a.OutLen <= 0 || a.In < 0 || a.In+a.InLen > len(in) {
+					t.Fatalf("Transcribe(%q) in %q: bad alignment %+v", in, n, res.Alignment)
+				}
+				out += a.OutLen
+			}
+			if c := utf8.RuneCountInString(res.IPA); out != c {
+				t.Errorf("Transcribe(%q) in %q: alignment covers %d runes of %d", in, n, out, c)
+			}
+		}
+	}
+}
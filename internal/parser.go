@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Node is an element of a parsed transcription: a *Segment, a *Notation or a
+// *Text.
+type Node interface {
+	node()
+}
+
+// SegmentKind decides which diacritic codes a segment accepts.
+type SegmentKind int
+
+const (
+	SegmentOther SegmentKind = iota
+	SegmentVowel
+	SegmentConsonant
+)
+
+// Stress is the stress level marked on a vowel.
+type Stress int
+
+const (
+	StressNone Stress = iota
+	StressPrimary
+	StressSecondary
+)
+
+// Segment is a single phonetic segment with the modifiers attached to it.
+type Segment struct {
+	// Symbol is the key symbol of the segment, e.g. "ə" for a typed 3.
+	Symbol string
+	// Base is the IPA base symbol, after any hushing.
+	Base     string
+	Kind     SegmentKind
+	Mods     []Modifier
+	Stress   Stress
+	Syllabic bool
+	Long     bool
+	// Break is set when the segment is followed by a syllable break.
+	Break bool
+	// Pos and Len are the byte span of the segment's symbol in the input.
+	Pos int
+	Len int
+	// StressAt, LongAt and BreakAt are the input spans of the codes that set
+	// Stress or Syllabic, Long and Break.
+	StressAt Span
+	LongAt   Span
+	BreakAt  Span
+}
+
+// Span is a byte range of the input.
+type Span struct {
+	Pos int
+	Len int
+}
+
+// Modifier is a diacritic code attached to a segment. Codes that the segment
+// does not accept are kept with Applied unset and rendered as typed.
+type Modifier struct {
+	Code    string
+	Mark    string
+	Pos     int
+	Applied bool
+}
+
+// Notation is an editorial notation with its gloss.
+type Notation struct {
+	Code  string
+	Gloss string
+	Arg   string
+	Pos   int
+	// Unterminated is set when Arg was not ended by the terminator.
+	Unterminated bool
+	// Len is the length in bytes of the notation in the input.
+	Len int
+}
+
+// Text is source text with no phonetic or editorial meaning.
+type Text struct {
+	Source string
+	Value  string
+	Pos    int
+	// Unknown is set when Source looks like a notation code that is not in
+	// the key.
+	Unknown bool
+}
+
+func (*Segment) node()  {}
+func (*Notation) node() {}
+func (*Text) node()     {}
+
+// Step is one rule application recorded when explaining a transcription:
+// the rule replaced Match, at Pos in the input, with Replacement.
+type Step struct {
+	// Rule identifies the rule, e.g. "vowel:94 (extra short)" for the
+	// vowel diacritic 94 or "notation:Q(ED)".
+	Rule        string `json:"rule"`
+	Match       string `json:"match"`
+	Replacement string `json:"replacement"`
+	// Pos is the byte offset of Match in the input.
+	Pos int `json:"pos"`
+	// Output is the rendered transcription after the step.
+	Output string `json:"output"`
+}
+
+// Parse builds the sequence of nodes described by toks.
+func Parse(toks []Token) []Node {
+	nodes, _ := parse(context.Background(), toks, nil)
+	return nodes
+}
+
+// parse is Parse that, if trace is not nil, also records every rule it
+// applies. It gives up with the error of ctx once ctx is done.
+func parse(ctx context.Context, toks []Token, trace *[]Step) ([]Node, error) {
+	var nodes []Node
+	var cur *Segment
+	record := func(rule, repl string, t Token) {
+		if trace != nil {
+			*trace = append(*trace, Step{Rule: rule, Match: t.Text, Replacement: repl, Pos: t.Pos, Output: Render(nodes)})
+		}
+	}
+
+	for i, t := range toks {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		switch t.Kind {
+		case TokenSegment:
+			cur = newSegment(t.Text, t.Pos)
+			nodes = append(nodes, cur)
+			record(segmentRule(t.Text), cur.Base, t)
+			continue
+		case TokenDiacritic:
+			if cur == nil {
+				break
+			}
+			if t.Text == key.breakCode {
+				if cur.Kind == SegmentVowel {
+					cur.Break = true
+					cur.BreakAt = Span{t.Pos, len(t.Text)}
+					record("break:"+t.Text, syllableBreak, t)
+				} else {
+					cur = newSegment(t.Text, t.Pos)
+					nodes = append(nodes, cur)
+					record("glottal:"+t.Text, cur.Base, t)
+				}
+				continue
+			}
+			rule, repl := cur.modify(t.Text, t.Pos)
+			record(rule, repl, t)
+			continue
+		case TokenStress:
+			if cur == nil {
+				break
+			}
+			rule, repl := cur.stress(t.Text, t.Pos)
+			record(rule, repl, t)
+			continue
+		case TokenLength:
+			if cur == nil {
+				nodes = append(nodes, &Text{Source: t.Text, Value: lengthMark, Pos: t.Pos})
+			} else {
+				cur.Long = true
+				cur.LongAt = Span{t.Pos, len(t.Text)}
+			}
+			record("length:"+t.Text, lengthMark, t)
+			continue
+		case TokenNotation:
+			cur = nil
+			n := len(nodes)
+			nodes = appendNotation(nodes, t)
+			for _, n := range nodes[n:] {
+				n := n.(*Notation)
+				record("notation:"+n.Code, n.Text(), Token{Text: n.Code, Pos: n.Pos})
+			}
+			continue
+		case TokenUnknown:
+			cur = nil
+			nodes = append(nodes, &Text{Source: t.Text, Value: t.Text, Pos: t.Pos, Unknown: true})
+			continue
+		}
+		cur = nil
+		nodes = append(nodes, &Text{Source: t.Text, Value: t.Text, Pos: t.Pos})
+	}
+	return nodes, nil
+}
+
+// segmentRule identifies the rule that turns the typed symbol text into a
+// segment.
+func segmentRule(text string) string {
+	if _, ok := lookupRule(key.basicRules, text); ok {
+		return "typed:" + text
+	}
+	if _, ok := lookupRule(key.baseRules, text); ok {
+		return "ipa:" + text
+	}
+	return "symbol:" + text
+}
+
+// appendNotation appends the notation t and any notations nested in its
+// argument.
+func appendNotation(nodes []Node, t Token) []Node {
+	nodes = append(nodes, &Notation{
+		Code:         t.notation.code,
+		Gloss:        t.notation.gloss,
+		Arg:          t.Arg,
+		Pos:          t.Pos,
+		Unterminated: t.notation.arg == argFree && t.Unterminated,
+		Len:          len(t.Text),
+	})
+	for _, n := range t.Nested {
+		nodes = appendNotation(nodes, n)
+	}
+	return nodes
+}
+
+func newSegment(text string, pos int) *Segment {
+	sym := text
+	if v, ok := lookupRule(key.basicRules, text); ok {
+		sym = v
+	}
+	base := sym
+	if v, ok := lookupRule(key.baseRules, sym); ok {
+		base = v
+	}
+
+	s := &Segment{Symbol: sym, Base: base, Pos: pos, Len: len(text)}
+	switch {
+	case strings.Contains(key.vowelSymbols, sym):
+		s.Kind = SegmentVowel
+	case strings.Contains(key.consonantSymbols, sym):
+		s.Kind = SegmentConsonant
+	}
+	return s
+}
+
+// modify applies the diacritic code to s. It returns the rule applied and
+// what it produced.
+func (s *Segment) modify(code string, pos int) (string, string) {
+	var keys []diacriticKey
+	switch s.Kind {
+	case SegmentVowel:
+		keys = key.vowelKeys
+	case SegmentConsonant:
+		keys = key.consKeys
+	}
+
+	for _, k := range keys {
+		if k.code != code || !strings.Contains(k.accepts, s.Symbol) {
+			continue
+		}
+		repl := k.mark
+		if k.replace != nil {
+			s.Base, _ = lookupRule(k.replace, s.Symbol)
+			repl = s.Base
+		}
+		s.Mods = append(s.Mods, Modifier{Code: code, Mark: k.mark, Pos: pos, Applied: true})
+		return diacriticRule(s.Kind, k), repl
+	}
+	s.Mods = append(s.Mods, Modifier{Code: code, Pos: pos})
+	return "unapplied:" + code, code
+}
+
+func diacriticRule(kind SegmentKind, k diacriticKey) string {
+	table := "consonant"
+	if kind == SegmentVowel {
+		table = "vowel"
+	}
+	return fmt.Sprintf("%s:%s (%s)", table, k.code, k.name)
+}
+
+// stress applies the stress code to s. It returns the rule applied and what
+// it produced. Only the first stress code of a segment applies; any more are
+// kept unapplied.
+func (s *Segment) stress(code string, pos int) (string, string) {
+	var rule, repl string
+	switch {
+	case s.StressAt.Len != 0:
+		s.Mods = append(s.Mods, Modifier{Code: code, Pos: pos})
+		return "unapplied:" + code, code
+	case s.Kind == SegmentVowel && code == ",,":
+		s.Stress = StressPrimary
+		rule, repl = "stress:"+code, primaryMark
+	case s.Kind == SegmentVowel:
+		s.Stress = StressSecondary
+		rule, repl = "stress:"+code, secondaryMark
+	case s.Kind == SegmentConsonant && code == ",":
+		s.Syllabic = true
+		rule, repl = "syllabic:"+code, syllabicMark
+	default:
+		s.Mods = append(s.Mods, Modifier{Code: code, Pos: pos})
+		return "unapplied:" + code, code
+	}
+	s.StressAt = Span{pos, len(code)}
+	return rule, repl
+}
+
+// Text returns the gloss of the notation with its argument filled in.
+func (n *Notation) Text() string {
+	return strings.Replace(n.Gloss, "%s", n.Arg, 1)
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// describe gives a short description of n for comparing parses: the IPA base
+// of a segment followed by its modifiers, the code of a notation followed by
+// its argument, or the value of a text.
+func describe(n Node) string {
+	switch n := n.(type) {
+	case *Segment:
+		var b strings.Builder
+		b.WriteString(n.Base)
+		for _, m := range n.Mods {
+			if m.Applied {
+				fmt.Fprintf(&b, " %s", m.Code)
+			} else {
+				fmt.Fprintf(&b, " !%s", m.Code)
+			}
+		}
+		switch n.Stress {
+		case StressPrimary:
+			b.WriteString(" primary")
+		case StressSecondary:
+			b.WriteString(" secondary")
+		}
+		if n.Syllabic {
+			b.WriteString(" syllabic")
+		}
+		if n.Long {
+			b.WriteString(" long")
+		}
+		if n.Break {
+			b.WriteString(" break")
+		}
+		return b.String()
+	case *Notation:
+		if n.Arg != "" {
+			return n.Code + " " + n.Arg
+		}
+		return n.Code
+	case *Text:
+		if n.Unknown {
+			return "unknown " + n.Value
+		}
+		return "text " + n.Value
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"a94,, s+", []string{"a 94 primary", "text  ", "ʃ +"}},
+		{"3s1n6", []string{"ə", "s", "ɪ", "n", "ʌ"}},
+		{"a.", []string{"a long"}},
+		{". a", []string{"text ː", "text  ", "a"}},
+		{"n,", []string{"n syllabic"}},
+		{"l8e,", []string{"l 8", "e secondary"}},
+		{"e95", []string{"e break"}},
+		{"k95", []string{"k", "ʔ"}},
+		{"c+u", []string{"tʃ +", "u"}},
+		{"c2", []string{"ts 2"}},
+
+		// Codes a segment does not accept are kept but not applied.
+		{"k94", []string{"k !94"}},
+		{"b,,", []string{"b !,,"}},
+
+		// Detached , + and - are not modifiers.
+		{"a +", []string{"a", "text  ", "+"}},
+		{", a", []string{"text ,", "text  ", "a"}},
+		{"a-", []string{"a", "text -"}},
+
+		{"Q(ED) x Q(S) y QP z QP", []string{"Q(ED) x z", "Q(S) y"}},
+		{"Q(R)b", []string{"Q(R)", "b"}},
+		{"Q(XYZ) a", []string{"unknown Q(XYZ)", "text  ", "a"}},
+		{"Q(ED", []string{"unknown Q(", "text E", "text D"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, n := range Parse(Lex(tt.in)) {
+			got = append(got, describe(n))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(Lex(%q)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestBaseline compares the output with what the regex rewrites Transcribe
+// used before the lexer and parser gave for the same input. Phonetic input is
+// transcribed as before; where notations are involved the old output was
+// wrong, and want records what is given now.
+func TestBaseline(t *testing.T) {
+	tests := []struct {
+		in, old, want string
+	}{
+		{in: "a94,, s+", old: "ˈă ʃ"},
+		{in: "bet", old: "bet"},
+		{in: "ka4n", old: "ka̞n"},
+		{in: "pi,,t7", old: "pˈitˠ"},
+		{in: "s7o5", old: "ʂ̻o̝"},
+		{in: "c+u", old: "tʃu"},
+		{in: "z2e8", old: "z̥e̟"},
+		{in: "d2a7", old: "d̥a̠"},
+		{in: "n,", old: "n̩"},
+		{in: "b+o.", old: "bⁿoː"},
+		{in: "e95", old: "e."},
+		{in: "3s1n6", old: "əsɪnʌ"},
+		{in: "l8e,", old: "lʲˌe"},
+		{in: "ts2", old: "ts̬"},
+		{in: "a CLN b", old: "a : b"},
+
+		// The old rewrites ran the glosses together with what followed them.
+		{in: "mo0", old: "moquestion not asked", want: "mo question not asked"},
+		{in: "x ||y", old: "x  is different fromy", want: "x is different from y"},
+		{in: "a + BUT ok QP", old: "a yes but:  ok ", want: "a yes but: ok"},
+		// They read every Q( as the ( notation and transcribed the code.
+		{in: "Q(R) r", old: "q relevant to problem number in dialectologyr) r", want: "rare r"},
+		{in: "Q(ED) comment QP", old: "q relevant to problem number in dialectologyed) tsomment qp", want: "editor's comments follow: comment"},
+		{in: "Q(ZZ)", old: "q relevant to problem number in dialectologyzz)", want: "interviewer's comment: not elicitable"},
+		{in: "//abc", old: " relevant to problem number in dialectologyabts)", want: "(abc)"},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		if want == "" {
+			want = tt.old
+		}
+		if got := Transcribe(tt.in, Options{}).Inline(); got != want {
+			t.Errorf("Transcribe(%q) = %q, want %q (before: %q)", tt.in, got, want, tt.old)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Record is one response of the LCAAJ: what an informant at one locality
+// answered to one questionnaire item, in key notation, together with its
+// transcription.
+type Record struct {
+	// Question is the number of the questionnaire item as the Atlas writes
+	// it, e.g. "125" or "125a".
+	Question string `json:"question"`
+	// Location is the code of the informant's locality.
+	Location string `json:"location"`
+	// Informant tells apart informants from one location. It may be empty.
+	Informant string `json:"informant,omitempty"`
+	// Response is the answer as written in the protocol, in key notation.
+	Response string `json:"response"`
+
+	// IPA and the fields after it are the transcription of Response, filled
+	// in by Transcribe. Values sent in requests are replaced.
+	IPA         string       `json:"ipa"`
+	Annotations []Annotation `json:"annotations"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	KeyVersion  string       `json:"keyVersion"`
+	// Alphabet and Output are as in Result.
+	Alphabet string `json:"alphabet,omitempty"`
+	Output   string `json:"output,omitempty"`
+}
+
+// Validate reports whether r identifies the response it holds: it needs a
+// question and a location. Surrounding space is trimmed from both.
+func (r *Record) Validate() error {
+	r.Question = strings.TrimSpace(r.Question)
+	r.Location = strings.TrimSpace(r.Location)
+	r.Informant = strings.TrimSpace(r.Informant)
+	switch {
+	case r.Question == "":
+		return errors.New(`missing "question"`)
+	case r.Location == "":
+		return errors.New(`missing "location"`)
+	}
+	return nil
+}
+
+// Transcribe fills in the transcription of r.Response. It gives up with the
+// error of ctx once ctx is done.
+func (r *Record) Transcribe(ctx context.Context, opts Options) error {
+	res, err := TranscribeContext(ctx, r.Response, opts)
+	if err != nil {
+		return err
+	}
+	r.Response = res.Input
+	r.IPA = res.IPA
+	r.Annotations = res.Annotations
+	r.Diagnostics = res.Diagnostics
+	r.KeyVersion = res.KeyVersion
+	r.Alphabet = res.Alphabet
+	r.Output = res.Output
+	return nil
+}
+
+// recordsBody is the body of an APITranscribeRecords request and response.
+type recordsBody struct {
+	Records []Record `json:"records"`
+	// Alphabet and Normalize are as in an APITranscribe request and apply
+	// to every record.
+	Alphabet  string `json:"alphabet,omitempty"`
+	Normalize string `json:"normalize,omitempty"`
+}
+
+// transcribeRecords validates and transcribes records in place. It gives up
+// with the error of ctx once ctx is done.
+func transcribeRecords(ctx context.Context, records []Record, opts Options) error {
+	for i := range records {
+		if err := records[i].Validate(); err != nil {
+			return fmt.Errorf("records[%d]: %w", i, err)
+		}
+	}
+	for i := range records {
+		if err := records[i].Transcribe(ctx, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// APITranscribeRecords transcribes the response of every record sent and
+// returns the records with their transcriptions.
+func APITranscribeRecords(w http.ResponseWriter, r *http.Request) {
+	body := &recordsBody{}
+	if !readJSON(w, r, body) {
+		return
+	}
+	if len(body.Records) > maxBatchItems {
+		writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("request has %d records, more than %d", len(body.Records), maxBatchItems))
+		return
+	}
+	opts, err := requestOptions(body.Alphabet, body.Normalize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if err := transcribeRecords(r.Context(), body.Records, opts); err != nil {
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			writeError(w, r, http.StatusServiceUnavailable, fmt.Errorf("records not finished: %w", ctxErr))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if body.Records == nil {
+		body.Records = []Record{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
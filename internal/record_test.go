@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPITranscribeRecords(t *testing.T) {
+	body := `{"records": [
+		{"question": " 125 ", "location": "48195", "informant": "2", "response": "a94"},
+		{"question": "126", "location": "48195", "response": "", "ipa": "stale"}
+	], "alphabet": "x-sampa"}`
+	w := httptest.NewRecorder()
+	APITranscribeRecords(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var got recordsBody
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Records) != 2 {
+		t.Fatalf("got %d records, want 2", len(got.Records))
+	}
+	want := Transcribe("a94", Options{Alphabet: xsampaAlphabet})
+	r := got.Records[0]
+	if r.Question != "125" || r.Location != "48195" || r.Informant != "2" || r.Response != "a94" {
+		t.Errorf("record metadata = %+v, want it kept with space trimmed", r)
+	}
+	if r.IPA != want.IPA || r.Output != want.Output || r.Alphabet != "x-sampa" || r.KeyVersion != want.KeyVersion {
+		t.Errorf("record transcription = %+v, want IPA %q and output %q", r, want.IPA, want.Output)
+	}
+	if got.Records[1].IPA != "" {
+		t.Errorf("IPA sent in the request was kept: %q", got.Records[1].IPA)
+	}
+
+	for _, body := range []string{
+		`{"records": [{"location": "48195", "response": "a"}]}`,
+		`{"records": [{"question": "1", "response": "a"}]}`,
+		`{"records": [{"question": "1", "location": "2", "response": "a", "speaker": "x"}]}`,
+	} {
+		w := httptest.NewRecorder()
+		APITranscribeRecords(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sammyshear/lcaaj-transcriber/views"
+	datastar "github.com/starfederation/datastar/sdk/go"
+)
+
+// defaultListLimit is how many records a list request returns if it does
+// not say.
+const defaultListLimit = 100
+
+// recordsAPI serves the records of a store.
+type recordsAPI struct {
+	store *Store
+}
+
+// RecordsHandler serves the records of s, to be mounted at /api/records:
+//
+//	GET    /         lists records, filtered by the question and location
+//	                 query parameters and paged by limit and offset
+//	POST   /         transcribes and saves a record
+//	GET    /{id}     returns a record
+//	PUT    /{id}     transcribes and saves a record over an existing one
+//	DELETE /{id}     deletes a record
+//
+// A record sent is transcribed in the alphabet it names, if any, and in the
+// normalization form named by its normalize field, which is not saved.
+func RecordsHandler(s *Store) http.Handler {
+	api := &recordsAPI{store: s}
+	r := chi.NewRouter()
+	r.NotFound(NotFound)
+	r.MethodNotAllowed(MethodNotAllowed)
+	r.Get("/", api.list)
+	r.Post("/", api.create)
+	r.Get("/{id}", api.get)
+	r.Put("/{id}", api.update)
+	r.Delete("/{id}", api.delete)
+	return r
+}
+
+func (api *recordsAPI) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := RecordFilter{Question: q.Get("question"), Location: q.Get("location"), Limit: defaultListLimit}
+	for _, p := range []struct {
+		name string
+		v    *int
+		max  int
+	}{{"limit", &f.Limit, maxBatchItems}, {"offset", &f.Offset, -1}} {
+		s := q.Get(p.name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || p.max >= 0 && n > p.max {
+			writeError(w, r, http.StatusBadRequest, fmt.Errorf("invalid %s %q", p.name, s))
+			return
+		}
+		*p.v = n
+	}
+
+	records, err := api.store.List(r.Context(), f)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Records []*StoredRecord `json:"records"`
+	}{records})
+}
+
+func (api *recordsAPI) create(w http.ResponseWriter, r *http.Request) {
+	rec := &StoredRecord{}
+	if !readRecord(w, r, rec) {
+		return
+	}
+	if err := api.store.Create(r.Context(), rec); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", recordURL(r, rec.ID))
+	writeJSON(w, http.StatusCreated, rec)
+}
+
+func (api *recordsAPI) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+	rec, err := api.store.Get(r.Context(), id)
+	if err != nil {
+		storeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (api *recordsAPI) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+	rec := &StoredRecord{}
+	if !readRecord(w, r, rec) {
+		return
+	}
+	rec.ID = id
+	if err := api.store.Update(r.Context(), rec); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (api *recordsAPI) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := recordID(w, r)
+	if !ok {
+		return
+	}
+	if err := api.store.Delete(r.Context(), id); err != nil {
+		storeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readRecord reads a record from the body of r, as returned by the API, and
+// transcribes it. Its ID and times are ignored. If it fails it responds
+// with an error and returns false.
+func readRecord(w http.ResponseWriter, r *http.Request, rec *StoredRecord) bool {
+	body := struct {
+		*StoredRecord
+		// Normalize is as in an APITranscribe request.
+		Normalize string `json:"normalize,omitempty"`
+	}{StoredRecord: rec}
+	if !readJSON(w, r, &body) {
+		return false
+	}
+	if err := rec.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return false
+	}
+	opts, err := requestOptions(rec.Alphabet, body.Normalize)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return false
+	}
+	if err := rec.Transcribe(r.Context(), opts); err != nil {
+		writeError(w, r, http.StatusServiceUnavailable, fmt.Errorf("transcription not finished: %w", err))
+		return false
+	}
+	return true
+}
+
+// recordID returns the record ID in the path of r. If there is none it
+// responds with an error and returns false.
+func recordID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	s := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, fmt.Errorf("no such record: %s", s))
+		return 0, false
+	}
+	return id, true
+}
+
+// recordURL returns the URL path of the record with the given ID, created by
+// a request to the collection. It is taken from the URI the client sent, so
+// that it includes any prefix the server is mounted under.
+func recordURL(r *http.Request, id int64) string {
+	p := r.URL.Path
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		p = u.Path
+	}
+	return strings.TrimSuffix(p, "/") + "/" + strconv.FormatInt(id, 10)
+}
+
+// storeError responds with the error a store returned. Errors other than
+// a missing record are logged rather than shown.
+func storeError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrNoRecord) {
+		writeError(w, r, http.StatusNotFound, err)
+		return
+	}
+	log.Printf("store (request %s): %v", middleware.GetReqID(r.Context()), err)
+	writeError(w, r, http.StatusInternalServerError, errors.New("internal server error"))
+}
+
+// DatastarSave returns a handler that transcribes each line of the input on
+// the page that is not blank and saves it in s as a record, with the
+// question, location and informant entered, and shows the outcome.
+func DatastarSave(s *Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := &dataSignal{}
+		r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+		if err := datastar.ReadSignals(r, data); err != nil {
+			writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		sse := datastar.NewSSE(w, r)
+		status := func(msg string) {
+			sse.MergeFragmentTempl(views.SaveStatus(msg), datastar.WithSelectorID("save-status"))
+		}
+		opts, err := requestOptions(data.Alphabet, data.Normalize)
+		if err != nil {
+			status("Not saved: " + err.Error())
+			return
+		}
+		var records []*StoredRecord
+		for _, line := range splitLines(data.Data) {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			rec := &StoredRecord{Record: Record{
+				Question:  data.Question,
+				Location:  data.Location,
+				Informant: data.Informant,
+				Response:  line,
+			}}
+			if err := rec.Validate(); err != nil {
+				status("Not saved: " + err.Error())
+				return
+			}
+			if err := rec.Transcribe(r.Context(), opts); err != nil {
+				status("Not saved: " + err.Error())
+				return
+			}
+			records = append(records, rec)
+		}
+		if len(records) == 0 {
+			status("Not saved: there is nothing to save")
+			return
+		}
+
+		if err := s.CreateAll(r.Context(), records); err != nil {
+			log.Printf("store (request %s): %v", middleware.GetReqID(r.Context()), err)
+			status("Not saved: the records could not be stored")
+			return
+		}
+		if len(records) == 1 {
+			status(fmt.Sprintf("Saved as record %d.", records[0].ID))
+			return
+		}
+		status(fmt.Sprintf("Saved %d lines as records.", len(records)))
+	}
+}
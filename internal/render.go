@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Alignment links a run of rendered output to the input that produced it.
+type Alignment struct {
+	// Out and OutLen are the run in runes of the output.
+	Out    int `json:"out"`
+	OutLen int `json:"outLen"`
+	// In and InLen are the byte span in the input.
+	In    int `json:"in"`
+	InLen int `json:"inLen"`
+}
+
+// output builds rendered text and records where each piece of it came from.
+type output struct {
+	b     strings.Builder
+	runes int
+	align []Alignment
+}
+
+func (o *output) write(s string, from Span) {
+	if s == "" {
+		return
+	}
+	n := utf8.RuneCountInString(s)
+	o.align = append(o.align, Alignment{Out: o.runes, OutLen: n, In: from.Pos, InLen: from.Len})
+	o.b.WriteString(s)
+	o.runes += n
+}
+
+func (o *output) endsInSpace() bool {
+	r, _ := utf8.DecodeLastRuneInString(o.b.String())
+	return o.b.Len() == 0 || unicode.IsSpace(r)
+}
+
+// IPA renders the segment with its stress, diacritics and length. Codes the
+// segment did not accept follow in the order they were typed.
+func (s *Segment) IPA() string {
+	o := &output{}
+	s.render(o)
+	return o.b.String()
+}
+
+func (s *Segment) render(o *output) {
+	switch s.Stress {
+	case StressPrimary:
+		o.write(primaryMark, s.StressAt)
+	case StressSecondary:
+		o.write(secondaryMark, s.StressAt)
+	}
+
+	// A base replaced by a code, as in hushing, comes from the code too.
+	base := Span{s.Pos, s.Len}
+	for _, m := range s.Mods {
+		if m.Applied && m.Mark == "" {
+			base.Len = max(base.Len, m.Pos+len(m.Code)-s.Pos)
+		}
+	}
+	o.write(s.Base, base)
+
+	for _, m := range s.marks() {
+		o.write(m.mark, m.from)
+	}
+	if s.Long {
+		o.write(lengthMark, s.LongAt)
+	}
+	if s.Break {
+		o.write(syllableBreak, s.BreakAt)
+	}
+	for _, m := range s.Mods {
+		if !m.Applied {
+			o.write(m.Code, Span{m.Pos, len(m.Code)})
+		}
+	}
+}
+
+// placedMark is a mark of a segment and the input span of the code that
+// set it.
+type placedMark struct {
+	mark string
+	from Span
+}
+
+// marks returns the marks of s in canonical order, whatever order their codes
+// were typed in: combining marks, then spacing ones such as ʲ, so that no
+// combining mark ends up on a spacing one. Within each group marks follow
+// the order of the key, with syllabicity after the key's own marks.
+func (s *Segment) marks() []placedMark {
+	var marks []placedMark
+	for _, m := range s.Mods {
+		if m.Applied && m.Mark != "" {
+			marks = append(marks, placedMark{m.Mark, Span{m.Pos, len(m.Code)}})
+		}
+	}
+	if s.Syllabic {
+		marks = append(marks, placedMark{syllabicMark, s.StressAt})
+	}
+	slices.SortStableFunc(marks, func(a, b placedMark) int {
+		return cmp.Compare(markRank(a.mark), markRank(b.mark))
+	})
+	return marks
+}
+
+// markRank is the place of mark in the canonical order of marks.
+func markRank(mark string) int {
+	isMark := func(k diacriticKey) bool { return k.mark == mark }
+	n := len(key.vowelKeys) + len(key.consKeys)
+	rank := n
+	if i := slices.IndexFunc(key.vowelKeys, isMark); i >= 0 {
+		rank = i
+	} else if i := slices.IndexFunc(key.consKeys, isMark); i >= 0 {
+		rank = len(key.vowelKeys) + i
+	}
+	if r, _ := utf8.DecodeRuneInString(mark); !unicode.Is(unicode.Mn, r) {
+		rank += n + 1
+	}
+	return rank
+}
+
+// Render renders nodes to a single string. Notation glosses are set off from
+// the surrounding text with spaces.
+func Render(nodes []Node) string {
+	o := &output{}
+	for i, n := range nodes {
+		switch n := n.(type) {
+		case *Segment:
+			n.render(o)
+		case *Notation:
+			from := Span{n.Pos, n.Len}
+			if !o.endsInSpace() {
+				o.write(" ", from)
+			}
+			o.write(n.Text(), from)
+			if i+1 < len(nodes) {
+				if _, ok := nodes[i+1].(*Segment); ok {
+					o.write(" ", from)
+				}
+			}
+		case *Text:
+			o.write(n.Value, Span{n.Pos, len(n.Source)})
+		}
+	}
+	return o.b.String()
+}
+
+// RenderPhonetic renders only the phonetic content of nodes, leaving out
+// notations and collapsing the whitespace around them.
+func RenderPhonetic(nodes []Node) string {
+	s, _ := renderPhonetic(nodes)
+	return s
+}
+
+// renderPhonetic is RenderPhonetic that also returns the alignment of the
+// output with the input.
+func renderPhonetic(nodes []Node) (string, []Alignment) {
+	o := &output{}
+	writePhonetic(o, nodes, func(s *Segment) {
+		s.render(o)
+	}, func(t *Text) {
+		o.write(t.Value, Span{t.Pos, len(t.Source)})
+	})
+	return o.b.String(), o.align
+}
+
+// writePhonetic writes the phonetic content of nodes to o with segment and
+// text, collapsing the whitespace around notations to a single space.
+func writePhonetic(o *output, nodes []Node, segment func(*Segment), text func(*Text)) {
+	space := Span{Pos: -1}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Notation:
+			continue
+		case *Text:
+			if strings.TrimSpace(n.Value) == "" {
+				if space.Pos < 0 {
+					space = Span{n.Pos, len(n.Source)}
+				}
+				continue
+			}
+		}
+		if space.Pos >= 0 && o.b.Len() > 0 {
+			o.write(" ", space)
+		}
+		space = Span{Pos: -1}
+
+		switch n := n.(type) {
+		case *Segment:
+			segment(n)
+		case *Text:
+			text(n)
+		}
+	}
+}
+
+// renderInline renders nodes like Render, but with their phonetic content in
+// alphabet a. Glosses are written as a writes text that is not phonetic.
+func renderInline(nodes []Node, a Alphabet) string {
+	var parts []string
+	start := 0
+	for i, n := range nodes {
+		if n, ok := n.(*Notation); ok {
+			if s := a.Render(nodes[start:i]); s != "" {
+				parts = append(parts, s)
+			}
+			gloss := n.Text()
+			if a, ok := a.(*symbolAlphabet); ok {
+				gloss = a.text(gloss)
+			}
+			parts = append(parts, gloss)
+			start = i + 1
+		}
+	}
+	if s := a.Render(nodes[start:]); s != "" {
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,302 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	// The pure Go SQLite driver, so the server still builds without cgo.
+	_ "modernc.org/sqlite"
+)
+
+// ErrNoRecord is returned for a record ID the store does not have.
+var ErrNoRecord = errors.New("no such record")
+
+// StoredRecord is a record saved in a Store.
+type StoredRecord struct {
+	ID int64 `json:"id"`
+	Record
+	// Created and Updated are when the record was saved first and last.
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// RecordFilter selects the records List returns. Empty fields match every
+// record.
+type RecordFilter struct {
+	Question string
+	Location string
+	// Limit is the most records to return, if positive, and Offset how many
+	// matching records to skip first.
+	Limit  int
+	Offset int
+}
+
+// Store keeps transcribed records in a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// migrations are the statements that bring the schema from each version to
+// the next. The version of a database is its user_version.
+var migrations = []string{
+	`CREATE TABLE records (
+		id          INTEGER PRIMARY KEY,
+		question    TEXT NOT NULL,
+		location    TEXT NOT NULL,
+		informant   TEXT NOT NULL DEFAULT '',
+		response    TEXT NOT NULL,
+		ipa         TEXT NOT NULL,
+		annotations TEXT NOT NULL,
+		diagnostics TEXT NOT NULL,
+		key_version TEXT NOT NULL,
+		alphabet    TEXT NOT NULL DEFAULT '',
+		output      TEXT NOT NULL DEFAULT '',
+		created     TEXT NOT NULL,
+		updated     TEXT NOT NULL
+	);
+	CREATE INDEX records_question_location ON records (question, location);
+	CREATE INDEX records_location ON records (location);`,
+}
+
+// OpenStore opens the SQLite database at path, creating it if it does not
+// exist, and brings its schema up to date.
+func OpenStore(path string) (*Store, error) {
+	// SQLite reads the name as a URI, so ?, # and % in the path are
+	// escaped; OmitHost keeps a relative path relative.
+	dsn := url.URL{
+		Scheme:   "file",
+		OmitHost: true,
+		Path:     path,
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this server's %d", version, len(migrations))
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+const recordColumns = `id, question, location, informant, response, ipa, annotations, diagnostics, key_version, alphabet, output, created, updated`
+
+// Create saves r as a new record, setting its ID and times.
+func (s *Store) Create(ctx context.Context, r *StoredRecord) error {
+	return s.CreateAll(ctx, []*StoredRecord{r})
+}
+
+// CreateAll saves records as new records, setting their IDs and times. They
+// are saved in one transaction, so if one cannot be saved none is.
+func (s *Store) CreateAll(ctx context.Context, records []*StoredRecord) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, r := range records {
+		r.Created, r.Updated = now, now
+		if err := insertRecord(ctx, tx, r); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertRecord(ctx context.Context, tx *sql.Tx, r *StoredRecord) error {
+	annotations, diagnostics, err := encodeLists(&r.Record)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO records (question, location, informant, response, ipa, annotations, diagnostics, key_version, alphabet, output, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Question, r.Location, r.Informant, r.Response, r.IPA, annotations, diagnostics, r.KeyVersion, r.Alphabet, r.Output,
+		formatTime(r.Created), formatTime(r.Updated))
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// Get returns the record with the given ID.
+func (s *Store) Get(ctx context.Context, id int64) (*StoredRecord, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+recordColumns+` FROM records WHERE id = ?`, id)
+	r, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoRecord
+	}
+	return r, err
+}
+
+// List returns the records f selects, in the order they were created.
+func (s *Store) List(ctx context.Context, f RecordFilter) ([]*StoredRecord, error) {
+	var where []string
+	var args []any
+	if f.Question != "" {
+		where = append(where, "question = ?")
+		args = append(args, f.Question)
+	}
+	if f.Location != "" {
+		where = append(where, "location = ?")
+		args = append(args, f.Location)
+	}
+	query := `SELECT ` + recordColumns + ` FROM records`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id`
+	if f.Limit > 0 || f.Offset > 0 {
+		limit := f.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, f.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := []*StoredRecord{}
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// Update replaces the record with the ID of r by r, keeping the time it was
+// created and setting the time it was updated.
+func (s *Store) Update(ctx context.Context, r *StoredRecord) error {
+	annotations, diagnostics, err := encodeLists(&r.Record)
+	if err != nil {
+		return err
+	}
+	r.Updated = time.Now().UTC()
+	row := s.db.QueryRowContext(ctx, `UPDATE records SET question = ?, location = ?, informant = ?, response = ?, ipa = ?, annotations = ?, diagnostics = ?, key_version = ?, alphabet = ?, output = ?, updated = ?
+		WHERE id = ? RETURNING created`,
+		r.Question, r.Location, r.Informant, r.Response, r.IPA, annotations, diagnostics, r.KeyVersion, r.Alphabet, r.Output,
+		formatTime(r.Updated), r.ID)
+	var created string
+	if err := row.Scan(&created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+	r.Created, err = time.Parse(time.RFC3339Nano, created)
+	return err
+}
+
+// Delete removes the record with the given ID.
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM records WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
+
+// encodeLists returns the annotations and diagnostics of r as JSON, which is
+// how they are stored.
+func encodeLists(r *Record) (annotations, diagnostics string, err error) {
+	a, err := json.Marshal(nonNil(r.Annotations))
+	if err != nil {
+		return "", "", err
+	}
+	d, err := json.Marshal(nonNil(r.Diagnostics))
+	if err != nil {
+		return "", "", err
+	}
+	return string(a), string(d), nil
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// scanRecord reads a record selected with recordColumns.
+func scanRecord(row interface{ Scan(...any) error }) (*StoredRecord, error) {
+	r := &StoredRecord{}
+	var annotations, diagnostics, created, updated string
+	err := row.Scan(&r.ID, &r.Question, &r.Location, &r.Informant, &r.Response, &r.IPA, &annotations, &diagnostics,
+		&r.KeyVersion, &r.Alphabet, &r.Output, &created, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(annotations), &r.Annotations); err != nil {
+		return nil, fmt.Errorf("record %d: annotations: %w", r.ID, err)
+	}
+	if err := json.Unmarshal([]byte(diagnostics), &r.Diagnostics); err != nil {
+		return nil, fmt.Errorf("record %d: diagnostics: %w", r.ID, err)
+	}
+	if r.Created, err = time.Parse(time.RFC3339Nano, created); err != nil {
+		return nil, fmt.Errorf("record %d: %w", r.ID, err)
+	}
+	if r.Updated, err = time.Parse(time.RFC3339Nano, updated); err != nil {
+		return nil, fmt.Errorf("record %d: %w", r.ID, err)
+	}
+	return r, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
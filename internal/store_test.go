@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordsHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.db")
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	h := RecordsHandler(s)
+
+	do := func(method, target, body string, status int, v any) {
+		t.Helper()
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != status {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, target, w.Code, status, w.Body)
+		}
+		if v != nil {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("%s %s: %v", method, target, err)
+			}
+		}
+	}
+
+	var created StoredRecord
+	do("POST", "/", `{"question": "125", "location": "48195", "response": "a94"}`, http.StatusCreated, &created)
+	want := Transcribe("a94", Options{})
+	if created.ID == 0 || created.IPA != want.IPA || created.KeyVersion != want.KeyVersion || created.Created.IsZero() {
+		t.Errorf("created record %+v, want an ID, times and IPA %q", created, want.IPA)
+	}
+	do("POST", "/", `{"question": "126", "location": "48195", "response": "o", "alphabet": "x-sampa"}`, http.StatusCreated, nil)
+	do("POST", "/", `{"question": "126", "response": "o"}`, http.StatusBadRequest, nil)
+
+	var got StoredRecord
+	do("GET", "/1", "", http.StatusOK, &got)
+	if got.ID != created.ID || got.IPA != created.IPA || !got.Created.Equal(created.Created) {
+		t.Errorf("GET /1 = %+v, want %+v", got, created)
+	}
+
+	var list struct{ Records []StoredRecord }
+	do("GET", "/?question=126", "", http.StatusOK, &list)
+	if len(list.Records) != 1 || list.Records[0].Output == "" {
+		t.Errorf("GET ?question=126 = %+v, want one record in X-SAMPA", list.Records)
+	}
+	do("GET", "/?limit=1&offset=1", "", http.StatusOK, &list)
+	if len(list.Records) != 1 || list.Records[0].Question != "126" {
+		t.Errorf("GET ?limit=1&offset=1 = %+v, want the second record", list.Records)
+	}
+	do("GET", "/?limit=x", "", http.StatusBadRequest, nil)
+
+	var updated StoredRecord
+	do("PUT", "/1", `{"question": "125", "location": "48195", "response": "u"}`, http.StatusOK, &updated)
+	if updated.IPA != Transcribe("u", Options{}).IPA || !updated.Created.Equal(created.Created) || !updated.Updated.After(created.Updated) {
+		t.Errorf("updated record %+v, want it transcribed again with its creation time kept", updated)
+	}
+	do("PUT", "/1", `{"question": "125", "location": "48195", "response": "a94+", "normalize": "nfc"}`, http.StatusOK, &updated)
+	if want := Transcribe("a94+", Options{Normalization: NFC}).IPA; updated.IPA != want {
+		t.Errorf("record sent with normalize nfc has IPA %q, want %q", updated.IPA, want)
+	}
+	do("PUT", "/1", `{"question": "125", "location": "48195", "response": "u", "normalize": "nfx"}`, http.StatusBadRequest, nil)
+	do("PUT", "/99", `{"question": "1", "location": "2", "response": "u"}`, http.StatusNotFound, nil)
+
+	do("DELETE", "/1", "", http.StatusNoContent, nil)
+	do("GET", "/1", "", http.StatusNotFound, nil)
+	do("DELETE", "/1", "", http.StatusNotFound, nil)
+	do("GET", "/x", "", http.StatusNotFound, nil)
+
+	// The records are still there when the database is opened again.
+	s.Close()
+	if s, err = OpenStore(path); err != nil {
+		t.Fatal(err)
+	}
+	h = RecordsHandler(s)
+	do("GET", "/", "", http.StatusOK, &list)
+	if len(list.Records) != 1 {
+		t.Errorf("reopened store has %d records, want 1", len(list.Records))
+	}
+}
+
+func TestCreateAll(t *testing.T) {
+	s, err := OpenStore(filepath.Join(t.TempDir(), "records.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	ctx := context.Background()
+	if _, err := s.db.Exec(`CREATE TRIGGER refuse BEFORE INSERT ON records WHEN NEW.response = 'refused'
+		BEGIN SELECT RAISE(ABORT, 'refused'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	record := func(response string) *StoredRecord {
+		return &StoredRecord{Record: Record{Question: "1", Location: "2", Response: response}}
+	}
+	if err := s.CreateAll(ctx, []*StoredRecord{record("a"), record("refused")}); err == nil {
+		t.Fatal("CreateAll saved a refused record")
+	}
+	if list, err := s.List(ctx, RecordFilter{}); err != nil || len(list) != 0 {
+		t.Fatalf("after a failed CreateAll the store has %d records (%v), want none", len(list), err)
+	}
+	if err := s.CreateAll(ctx, []*StoredRecord{record("a"), record("b")}); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := s.List(ctx, RecordFilter{}); err != nil || len(list) != 2 {
+		t.Errorf("store has %d records (%v), want 2", len(list), err)
+	}
+}
+
+// TestOpenStorePath checks that the database is created at the path given,
+// whatever characters it has, and opened with the pragmas set.
+func TestOpenStorePath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, name := range []string{"what?.db", "a#b.db", "100% sure.db", "x.db?mode=ro", "relative.db"} {
+		path := name
+		if name != "relative.db" {
+			path = filepath.Join(dir, name)
+		}
+		s, err := OpenStore(path)
+		if err != nil {
+			t.Errorf("OpenStore(%q): %v", path, err)
+			continue
+		}
+		var mode string
+		var foreignKeys int
+		if err := s.db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil || mode != "wal" {
+			t.Errorf("OpenStore(%q): journal mode %q (%v), want wal", path, mode, err)
+		}
+		if err := s.db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil || foreignKeys != 1 {
+			t.Errorf("OpenStore(%q): foreign_keys %d (%v), want 1", path, foreignKeys, err)
+		}
+		s.Close()
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("OpenStore(%q) did not create the file: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TableFormat is a format of spreadsheet exports.
+type TableFormat string
+
+const (
+	CSV TableFormat = "csv"
+	TSV TableFormat = "tsv"
+)
+
+// ParseTableFormat returns the table format called name, in either case.
+func ParseTableFormat(name string) (TableFormat, error) {
+	switch f := TableFormat(strings.ToLower(name)); f {
+	case CSV, TSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown table format %q", name)
+}
+
+// ContentType is the media type of the format.
+func (f TableFormat) ContentType() string {
+	if f == TSV {
+		return "text/tab-separated-values; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// tableReader reads the rows of a table. The row returned is only valid
+// until the next call.
+type tableReader interface {
+	Read() ([]string, error)
+}
+
+// tableWriter writes the rows of a table.
+type tableWriter interface {
+	Write(row []string) error
+	Flush()
+	Error() error
+}
+
+func (f TableFormat) reader(r io.Reader) tableReader {
+	if f == TSV {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(nil, MaxBodySize)
+		return &tsvReader{sc: sc}
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return cr
+}
+
+func (f TableFormat) writer(w io.Writer) tableWriter {
+	if f == TSV {
+		return &tsvWriter{w: bufio.NewWriter(w)}
+	}
+	return csv.NewWriter(w)
+}
+
+// tsvReader reads tab-separated values, which, unlike CSV, have no quoting:
+// every line is a row and every tab separates fields.
+type tsvReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (r *tsvReader) Read() ([]string, error) {
+	if !r.sc.Scan() {
+		if err := r.sc.Err(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+		}
+		return nil, io.EOF
+	}
+	r.line++
+	return strings.Split(strings.TrimSuffix(r.sc.Text(), "\r"), "\t"), nil
+}
+
+// tsvWriter writes tab-separated values. Tabs and line breaks in fields,
+// which the format cannot hold, are written as spaces.
+type tsvWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+var tsvEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func (w *tsvWriter) Write(row []string) error {
+	for i, f := range row {
+		if i > 0 {
+			w.w.WriteByte('\t')
+		}
+		tsvEscaper.WriteString(w.w, f)
+	}
+	_, w.err = w.w.WriteString("\n")
+	return w.err
+}
+
+func (w *tsvWriter) Flush() {
+	if err := w.w.Flush(); w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *tsvWriter) Error() error {
+	return w.err
+}
+
+// TableOptions says how to read a table and transcribe it.
+type TableOptions struct {
+	Format TableFormat
+	// Column is the column holding the key notation: the name of a column
+	// in the header, in either case, or its number, counting from 1. If it
+	// is empty, it is the column named "response" if there is one and the
+	// first otherwise.
+	Column string
+	// NoHeader is set if the first row is data rather than column names.
+	NoHeader bool
+	// Options are the options of the transcription of each row.
+	Options Options
+	// Progress, if set, is called after each row with the number of rows
+	// written so far.
+	Progress func(rows int)
+}
+
+// TranscribeTable transcribes the notation in a column of every row of the
+// table read from r and writes the table to w with the IPA and annotations
+// of each row added as columns, followed by the transcription in
+// opts.Options.Alphabet if it is not IPA. The annotations of a row are
+// written as "code: gloss", separated by "; ". Rows may have different
+// lengths; one without the column gets empty transcriptions. It gives up
+// with the error of ctx once ctx is done.
+func TranscribeTable(ctx context.Context, w io.Writer, r io.Reader, opts TableOptions) error {
+	br := bufio.NewReader(r)
+	// Spreadsheets often save CSV with a byte order mark.
+	if b, err := br.Peek(3); err == nil && string(b) == "\uFEFF" {
+		br.Discard(3)
+	}
+	tr, tw := opts.Format.reader(br), opts.Format.writer(w)
+
+	col := -1
+	extra := []string{"ipa", "annotations"}
+	if a := opts.Options.Alphabet; a != nil && a.Name() != "ipa" {
+		extra = append(extra, a.Name())
+	}
+	for row := 0; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fields, err := tr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if row == 0 {
+			var header []string
+			if !opts.NoHeader {
+				header = fields
+			}
+			if col, err = tableColumn(header, opts.Column); err != nil {
+				return err
+			}
+			if header != nil {
+				if err := tw.Write(append(fields, extra...)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		added := make([]string, len(extra))
+		if col < len(fields) {
+			res, err := TranscribeContext(ctx, fields[col], opts.Options)
+			if err != nil {
+				return err
+			}
+			added[0] = res.IPA
+			added[1] = formatAnnotations(res.Annotations)
+			if len(added) > 2 {
+				added[2] = res.Output
+			}
+		}
+		if err := tw.Write(append(fields, added...)); err != nil {
+			return err
+		}
+		if opts.Progress != nil {
+			opts.Progress(row + 1)
+		}
+	}
+	tw.Flush()
+	return tw.Error()
+}
+
+// tableColumn returns the index of the column called name in header, which
+// is nil for a table without one.
+func tableColumn(header []string, name string) (int, error) {
+	if name == "" {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), "response") {
+				return i, nil
+			}
+		}
+		return 0, nil
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 1 {
+		return n - 1, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("column %q: a table without a header has only numbered columns", name)
+	}
+	return 0, fmt.Errorf("no column %q in the header", name)
+}
+
+func formatAnnotations(annotations []Annotation) string {
+	parts := make([]string, len(annotations))
+	for i, a := range annotations {
+		parts[i] = a.Code + ": " + a.Gloss
+	}
+	return strings.Join(parts, "; ")
+}
+
+// MaxTableSize is the largest table APITranscribeTable accepts, in bytes.
+const MaxTableSize = 16 << 20
+
+// APITranscribeTable transcribes a CSV or TSV table sent as the request
+// body and responds with the table with transcriptions added, as
+// TranscribeTable does. The query parameters format ("csv", the default, or
+// "tsv"), column, header ("0" if the table has none), alphabet and
+// normalize say how; the format may also be given by the Content-Type.
+func APITranscribeTable(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "text/tab-separated-values") {
+			format = "tsv"
+		}
+	}
+	f, err := ParseTableFormat(format)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	opts, err := requestOptions(q.Get("alphabet"), q.Get("normalize"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	var out bytes.Buffer
+	body := http.MaxBytesReader(w, r.Body, MaxTableSize)
+	err = TranscribeTable(r.Context(), &out, body, TableOptions{
+		Format:   f,
+		Column:   q.Get("column"),
+		NoHeader: q.Get("header") == "0",
+		Options:  opts,
+	})
+	if err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("table is larger than %d bytes", MaxTableSize))
+			return
+		}
+		if ctxErr := r.Context().Err(); ctxErr != nil {
+			writeError(w, r, http.StatusServiceUnavailable, fmt.Errorf("table not finished: %w", ctxErr))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", f.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="transcribed.%s"`, f))
+	w.Write(out.Bytes())
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTranscribeTable(t *testing.T) {
+	ipa := func(s string) string { return Transcribe(s, Options{}).IPA }
+	tests := []struct {
+		name string
+		in   string
+		opts TableOptions
+		want string
+	}{
+		{
+			"response column",
+			"\uFEFFquestion,location,response\n125,48195,a94\n126,48195,\"o, Q(ED) x\"\n127\n",
+			TableOptions{Format: CSV},
+			"question,location,response,ipa,annotations\n" +
+				"125,48195,a94," + ipa("a94") + ",\n" +
+				"126,48195,\"o, Q(ED) x\"," + ipa("o, Q(ED) x") + ",Q(ED): " + Transcribe("o, Q(ED) x", Options{}).Annotations[0].Gloss + "\n" +
+				"127,,\n",
+		},
+		{
+			"named column and alphabet",
+			"Form,n\na94,1\n",
+			TableOptions{Format: CSV, Column: "form", Options: Options{Alphabet: xsampaAlphabet}},
+			"Form,n,ipa,annotations,x-sampa\na94,1," + ipa("a94") + ",," + Transcribe("a94", Options{Alphabet: xsampaAlphabet}).Output + "\n",
+		},
+		{
+			"tsv without header",
+			"1\t\"a94\n2\tu\n",
+			TableOptions{Format: TSV, Column: "2", NoHeader: true},
+			"1\t\"a94\t" + ipa(`"a94`) + "\t\n2\tu\t" + ipa("u") + "\t\n",
+		},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := TranscribeTable(context.Background(), &b, strings.NewReader(tt.in), tt.opts); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, b.String(), tt.want)
+		}
+	}
+
+	for _, opts := range []TableOptions{
+		{Format: CSV, Column: "missing"},
+		{Format: CSV, Column: "response", NoHeader: true},
+	} {
+		if err := TranscribeTable(context.Background(), &strings.Builder{}, strings.NewReader("response\na\n"), opts); err == nil {
+			t.Errorf("column %q, no header %v: no error", opts.Column, opts.NoHeader)
+		}
+	}
+}
+
+func TestAPITranscribeTable(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?column=2", strings.NewReader("n\tform\n1\ta94\n"))
+	r.Header.Set("Content-Type", "text/tab-separated-values")
+	w := httptest.NewRecorder()
+	APITranscribeTable(w, r)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/tab-separated-values") {
+		t.Fatalf("status %d, Content-Type %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if want := "n\tform\tipa\tannotations\n1\ta94\t" + Transcribe("a94", Options{}).IPA + "\t\n"; w.Body.String() != want {
+		t.Errorf("got %q, want %q", w.Body, want)
+	}
+
+	w = httptest.NewRecorder()
+	APITranscribeTable(w, httptest.NewRequest(http.MethodPost, "/?format=xls", strings.NewReader("a\n")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"context"
+	"unicode/utf8"
+)
+
+// ctxCheckInterval is how many tokens are lexed or parsed between checks
+// of whether a transcription was cancelled.
+const ctxCheckInterval = 256
+
+// Options controls how a transcription is rendered.
+type Options struct {
+	// StripNotations leaves editorial notations out of the result instead of
+	// listing them as annotations.
+	StripNotations bool
+	// Explain records every rule applied in Result.Trace.
+	Explain bool
+	// Alphabet, if set to other than IPA, is the alphabet Result.Output is
+	// written in.
+	Alphabet Alphabet
+	// Normalization is applied to every rendering of the result.
+	Normalization Normalization
+}
+
+// Result is a transcription together with what is needed to check it. It is
+// the JSON form of an APITranscribe response.
+type Result struct {
+	// Input is the transcribed text, with any invalid UTF-8 replaced by
+	// U+FFFD.
+	Input string `json:"input"`
+	// IPA is the phonetic content of the input only.
+	IPA         string       `json:"ipa"`
+	Annotations []Annotation `json:"annotations"`
+	KeyVersion  string       `json:"keyVersion"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// Alignment maps runs of IPA to the input that produced them, in
+	// output order.
+	Alignment []Alignment `json:"alignment"`
+	// Trace lists the rules applied, in order, if Options.Explain was set.
+	Trace []Step `json:"trace,omitempty"`
+	// Alphabet and Output are the name of Options.Alphabet and the phonetic
+	// content written in it, if one was set.
+	Alphabet string `json:"alphabet,omitempty"`
+	Output   string `json:"output,omitempty"`
+	// Normalization is Options.Normalization.
+	Normalization Normalization `json:"normalization,omitempty"`
+
+	nodes    []Node
+	alphabet Alphabet
+}
+
+// Annotation is an editorial notation found in the input.
+type Annotation struct {
+	Code string `json:"code"`
+	// Gloss is the meaning of the code, with Text filled in if the code
+	// takes any.
+	Gloss string `json:"gloss"`
+	// Text is the free text attached to the code.
+	Text string `json:"text,omitempty"`
+	// Offset is the byte offset of the code in the input.
+	Offset int `json:"offset"`
+}
+
+// Transcribe converts LCAAJ key notation to IPA, keeping the phonetic content
+// apart from the editorial notations.
+func Transcribe(s string, opts Options) *Result {
+	res, _ := TranscribeContext(context.Background(), s, opts)
+	return res
+}
+
+// TranscribeContext is Transcribe that gives up with the error of ctx once
+// ctx is done, so that a request that times out stops using the CPU.
+func TranscribeContext(ctx context.Context, s string, opts Options) (*Result, error) {
+	var trace *[]Step
+	if opts.Explain {
+		trace = &[]Step{}
+	}
+	var invalid []Span
+	if !utf8.ValidString(s) {
+		s, invalid = replaceInvalid(s)
+	}
+	toks, err := lex(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := parse(ctx, toks, trace)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ipa, align := renderPhonetic(nodes)
+	ipa, align = opts.Normalization.align(ipa, align)
+	res := &Result{
+		Input:         s,
+		IPA:           ipa,
+		Annotations:   []Annotation{},
+		KeyVersion:    key.Version,
+		Normalization: opts.Normalization,
+		Diagnostics:   diagnose(s, nodes, invalid),
+		Alignment:     align,
+		nodes:         nodes,
+	}
+	if trace != nil {
+		res.Trace = *trace
+	}
+	if _, ipa := opts.Alphabet.(ipaAlphabet); opts.Alphabet != nil && !ipa {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Alphabet = opts.Alphabet.Name()
+		res.Output = opts.Normalization.Apply(opts.Alphabet.Render(nodes))
+		res.alphabet = opts.Alphabet
+	}
+	if opts.StripNotations {
+		return res, nil
+	}
+	for _, n := range nodes {
+		if n, ok := n.(*Notation); ok {
+			res.Annotations = append(res.Annotations, Annotation{Code: n.Code, Gloss: n.Text(), Text: n.Arg, Offset: n.Pos})
+		}
+	}
+	return res, nil
+}
+
+// Inline renders the transcription with the glosses of its notations in
+// place, or just the phonetic content if notations were stripped. It is in
+// Options.Alphabet if one was set and in IPA otherwise.
+func (r *Result) Inline() string {
+	if r.alphabet == nil {
+		if len(r.Annotations) == 0 {
+			return r.IPA
+		}
+		return r.Normalization.Apply(Render(r.nodes))
+	}
+	if len(r.Annotations) == 0 {
+		return r.Output
+	}
+	return r.Normalization.Apply(renderInline(r.nodes, r.alphabet))
+}
+
+// SourceOf returns the input span that produced the rune at offset out of
+// the IPA.
+func (r *Result) SourceOf(out int) (Span, bool) {
+	for _, a := range r.Alignment {
+		if out >= a.Out && out < a.Out+a.OutLen {
+			return Span{a.In, a.InLen}, true
+		}
+	}
+	return Span{}, false
+}
+
+// OutputOf returns the runs of IPA that the input byte at offset in
+// contributed to.
+func (r *Result) OutputOf(in int) []Alignment {
+	var runs []Alignment
+	for _, a := range r.Alignment {
+		if in >= a.In && in < a.In+a.InLen {
+			runs = append(runs, a)
+		}
+	}
+	return runs
+}
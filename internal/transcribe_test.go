@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// determinismRuns is how many times each input is transcribed when checking
+// that output does not vary between runs.
+const determinismRuns = 100
+
+// keyInputs returns one input per entry of the key tables, plus a few lines
+// that combine them.
+func keyInputs() []string {
+	inputs := []string{
+		"3b1c6",
+		"ka.ts",
+		"a94,,b s+8 c7",
+		"Q(ED) x QP and Q(ED) y QP",
+		"+ BUT foo QP -$ //abc",
+	}
+	for _, r := range key.basicRules {
+		inputs = append(inputs, r.from, "b"+r.from+".")
+	}
+	for _, k := range key.vowelKeys {
+		for _, v := range k.accepts {
+			inputs = append(inputs, string(v)+k.code, string(v)+k.code+",,", string(v)+k.code+"95")
+		}
+	}
+	for _, k := range key.consKeys {
+		for _, c := range k.accepts {
+			inputs = append(inputs, string(c)+k.code, "a"+string(c)+k.code+",")
+		}
+	}
+	for _, n := range key.notKeys {
+		inputs = append(inputs, n.code, "a "+n.code+" text QP b")
+	}
+	return inputs
+}
+
+func TestTranscribeDeterministic(t *testing.T) {
+	for _, in := range keyInputs() {
+		want := Transcribe(in, Options{}).Inline()
+		for range determinismRuns {
+			if got := Transcribe(in, Options{}).Inline(); got != want {
+				t.Fatalf("Transcribe(%q) = %q, earlier run gave %q", in, got, want)
+			}
+		}
+	}
+}
+
+// TestRulePriority checks that where key codes overlap, the longer code is
+// read rather than the shorter one it starts with.
+func TestRulePriority(t *testing.T) {
+	tests := []struct {
+		in, ipa, code string
+	}{
+		{in: "a94", ipa: "a\u0306"},
+		{in: "a4", ipa: "a\u031e"},
+		{in: "+ BUT foo QP", code: "+ BUT"},
+		{in: "+$", code: "+$"},
+		{in: "+", code: "+"},
+		{in: "- BUT foo QP", code: "- BUT"},
+		{in: "-$", code: "-$"},
+	}
+	for _, tt := range tests {
+		res := Transcribe(tt.in, Options{})
+		if res.IPA != tt.ipa {
+			t.Errorf("Transcribe(%q).IPA = %q, want %q", tt.in, res.IPA, tt.ipa)
+		}
+		var code string
+		if len(res.Annotations) == 1 {
+			code = res.Annotations[0].Code
+		}
+		if code != tt.code || len(res.Annotations) > 1 {
+			t.Errorf("Transcribe(%q).Annotations = %v, want one with code %q", tt.in, res.Annotations, tt.code)
+		}
+	}
+}
+
+func TestNotationText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Annotation
+	}{
+		{
+			in: "Q(ED) first QP a Q(ED) second QP",
+			want: []Annotation{
+				{Code: "Q(ED)", Gloss: "editor's comments follow: first", Text: "first", Offset: 0},
+				{Code: "Q(ED)", Gloss: "editor's comments follow: second", Text: "second", Offset: 17},
+			},
+		},
+		{
+			in: "Q(GLE) a bird QP",
+			want: []Annotation{
+				{Code: "Q(GLE)", Gloss: "informant's explanation in English: a bird", Text: "a bird", Offset: 0},
+			},
+		},
+		{
+			in: "Q(S) his wife Q(R)",
+			want: []Annotation{
+				{Code: "Q(S)", Gloss: "said by: his wife", Text: "his wife", Offset: 0},
+				{Code: "Q(R)", Gloss: "rare", Offset: 14},
+			},
+		},
+		{
+			in: "Q(W) old people",
+			want: []Annotation{
+				{Code: "Q(W)", Gloss: "used by: old people", Text: "old people", Offset: 0},
+			},
+		},
+		{
+			in: "Q(I) before Q(ED) inner QP after QP",
+			want: []Annotation{
+				{Code: "Q(I)", Gloss: "interviewer's comments follow: before after", Text: "before after", Offset: 0},
+				{Code: "Q(ED)", Gloss: "editor's comments follow: inner", Text: "inner", Offset: 12},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := Transcribe(tt.in, Options{}).Annotations
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Transcribe(%q).Annotations = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAlignment(t *testing.T) {
+	for _, in := range keyInputs() {
+		res := Transcribe(in, Options{})
+		out := 0
+		for _, a := range res.Alignment {
+			if a.Out != out || a.OutLen <= 0 {
+				t.Fatalf("Transcribe(%q).Alignment = %+v: run at %d does not continue from %d", in, res.Alignment, a.Out, out)
+			}
+			if a.In < 0 || a.InLen <= 0 || a.In+a.InLen > len(in) {
+				t.Fatalf("Transcribe(%q).Alignment = %+v: input span %d+%d out of range", in, res.Alignment, a.In, a.InLen)
+			}
+			out += a.OutLen
+		}
+		if n := utf8.RuneCountInString(res.IPA); out != n {
+			t.Errorf("Transcribe(%q).Alignment covers %d runes of %d", in, out, n)
+		}
+	}
+
+	res := Transcribe("s+a,, Q(R) b95", Options{})
+	tests := []struct {
+		out  int
+		want Span
+	}{
+		{0, Span{0, 2}}, // ʃ from s+
+		{1, Span{3, 2}}, // ˈ from ,,
+		{2, Span{2, 1}}, // a
+		{3, Span{5, 1}}, // the space before Q(R)
+		{4, Span{11, 1}},
+		{5, Span{12, 2}}, // ʔ from 95
+	}
+	for _, tt := range tests {
+		if got, ok := res.SourceOf(tt.out); !ok || got != tt.want {
+			t.Errorf("SourceOf(%d) in %q = %v, want %v", tt.out, res.IPA, got, tt.want)
+		}
+	}
+	if got := res.OutputOf(3); len(got) != 1 || got[0].Out != 1 {
+		t.Errorf("OutputOf(3) = %+v, want the stress mark", got)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	res := Transcribe("a94,, s+", Options{Explain: true})
+	var rules, outputs []string
+	for _, s := range res.Trace {
+		rules = append(rules, s.Rule)
+		outputs = append(outputs, s.Output)
+	}
+	want := []string{"symbol:a", "vowel:94 (extra short)", "stress:,,", "symbol:s", "consonant:+ (hushed)"}
+	if !slices.Equal(rules, want) {
+		t.Errorf("Trace rules = %q, want %q", rules, want)
+	}
+	wantOutputs := []string{"a", "a\u0306", "ˈa\u0306", "ˈa\u0306 s", "ˈa\u0306 ʃ"}
+	if !slices.Equal(outputs, wantOutputs) {
+		t.Errorf("Trace outputs = %q, want %q", outputs, wantOutputs)
+	}
+	if last := outputs[len(outputs)-1]; last != res.Inline() {
+		t.Errorf("output of the last step = %q, want the result %q", last, res.Inline())
+	}
+	if last := res.Trace[len(res.Trace)-1]; last.Match != "+" || last.Pos != 7 || last.Replacement != "ʃ" {
+		t.Errorf("last step = %+v, want + at 7 replaced with ʃ", last)
+	}
+
+	if res := Transcribe("a94", Options{}); res.Trace != nil {
+		t.Errorf("Trace without Explain = %+v", res.Trace)
+	}
+}
+
+// TestExplainBounded checks that explaining the longest input allowed stays
+// within reason, since every step carries the output so far.
+func TestExplainBounded(t *testing.T) {
+	in := strings.Repeat("a94,, s+ ", MaxExplainSize/len("a94,, s+ "))
+	b, err := json.Marshal(Transcribe(in, Options{Explain: true}).Trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) > 16<<20 {
+		t.Errorf("trace of a %d-byte input is %d bytes, want at most 16 MiB", len(in), len(b))
+	}
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// untypedBase is an IPA base symbol together with the key symbol it is
+// written with and the code that produced it, if any.
+type untypedBase struct {
+	ipa    string
+	symbol string
+	code   string
+}
+
+// Untranscribe converts IPA as produced by Transcribe back to LCAAJ key
+// notation. Only phonetic content is converted; glosses cannot be told apart
+// from phonetic text reliably and are passed through like anything else the
+// key does not produce. IPA in any normalization form is accepted.
+func Untranscribe(ipa string) string {
+	ipa = norm.NFD.String(ipa)
+	var b strings.Builder
+	afterVowel := false
+	for i := 0; i < len(ipa); {
+		n, typed, kind := untranscribeSegment(ipa[i:], afterVowel)
+		if n == 0 {
+			_, n = utf8.DecodeRuneInString(ipa[i:])
+			typed = ipa[i : i+n]
+		}
+		b.WriteString(typed)
+		// A , passed through is read as extra stress of the vowel before,
+		// so what follows it is still typed after that vowel.
+		if typed != "," {
+			afterVowel = kind == SegmentVowel
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// untranscribeSegment reads one segment with its marks from the start of s.
+// It returns the number of bytes read, or 0 if s does not start with a
+// segment. A segment directly after a vowel is typed as its IPA symbol if its
+// usual code would attach to the vowel instead, as 95 does.
+func untranscribeSegment(s string, afterVowel bool) (int, string, SegmentKind) {
+	stress := ""
+	rest := s
+	if after, ok := strings.CutPrefix(rest, primaryMark); ok {
+		stress, rest = ",,", after
+	} else if after, ok := strings.CutPrefix(rest, secondaryMark); ok {
+		stress, rest = ",", after
+	}
+
+	seg, n := untranscribeBase(rest)
+	if seg == nil || stress != "" && seg.Kind != SegmentVowel {
+		return 0, "", SegmentOther
+	}
+	rest = rest[n:]
+
+	var codes strings.Builder
+	for _, m := range seg.Mods {
+		codes.WriteString(m.Code)
+	}
+	for rest != "" {
+		code, n := untranscribeMark(seg, rest)
+		if n == 0 {
+			break
+		}
+		// Stress typed after the syllable break would be read as extra
+		// stress if the IPA goes on with a literal , or ,, code.
+		if code == key.breakCode {
+			codes.WriteString(stress)
+			stress = ""
+		}
+		codes.WriteString(code)
+		rest = rest[n:]
+	}
+	codes.WriteString(stress)
+
+	typed := seg.Symbol
+	if v, ok := untypedSymbol(typed); ok && !(afterVowel && slices.Contains(key.diacriticCodes, v)) {
+		typed = v
+	}
+	return len(s) - len(rest), typed + codes.String(), seg.Kind
+}
+
+// untranscribeBase reads an IPA base symbol from the start of s and returns
+// the segment it stands for, with any code that produced it recorded as a
+// modifier.
+func untranscribeBase(s string) (*Segment, int) {
+	for _, b := range key.untypedBases {
+		if strings.HasPrefix(s, b.ipa) {
+			seg := newSegment(b.symbol, 0)
+			if b.code != "" {
+				seg.Mods = append(seg.Mods, Modifier{Code: b.code, Applied: true})
+			}
+			return seg, len(b.ipa)
+		}
+	}
+
+	r, n := utf8.DecodeRuneInString(s)
+	sym := string(r)
+	if _, ok := untypedSymbol(sym); ok || strings.ContainsRune(key.vowelSymbols+key.consonantSymbols, r) {
+		return newSegment(sym, 0), n
+	}
+	return nil, 0
+}
+
+// untranscribeMark reads a mark following seg from the start of s and
+// returns the code it is typed with.
+func untranscribeMark(seg *Segment, s string) (string, int) {
+	switch {
+	case strings.HasPrefix(s, lengthMark):
+		return ".", len(lengthMark)
+	case strings.HasPrefix(s, syllabicMark) && seg.Kind == SegmentConsonant:
+		return ",", len(syllabicMark)
+	case strings.HasPrefix(s, syllableBreak) && seg.Kind == SegmentVowel:
+		return key.breakCode, len(syllableBreak)
+	}
+
+	keys := key.consKeys
+	if seg.Kind == SegmentVowel {
+		keys = key.vowelKeys
+	}
+	for _, k := range keys {
+		if k.mark != "" && strings.HasPrefix(s, k.mark) && strings.Contains(k.accepts, seg.Symbol) {
+			return k.code, len(k.mark)
+		}
+	}
+	return "", 0
+}
+
+// untypedSymbol returns how the key symbol sym is typed if that is not sym
+// itself.
+func untypedSymbol(sym string) (string, bool) {
+	for _, r := range key.basicRules {
+		if r.to == sym {
+			return r.from, true
+		}
+	}
+	return "", false
+}
+
+func APIUntranscribe(w http.ResponseWriter, r *http.Request) {
+	data := &dataSignal{}
+	if !readJSON(w, r, data) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(Untranscribe(data.Data)))
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// randomPhonetic returns a random line of phonetic key notation: segments
+// with codes they accept, separated now and then by spaces.
+func randomPhonetic(rnd *rand.Rand) string {
+	symbols := []string{"3", "1", "6", "95"}
+	for _, r := range key.vowelSymbols + key.consonantSymbols {
+		symbols = append(symbols, string(r))
+	}
+
+	var b strings.Builder
+	for range rnd.IntN(12) {
+		sym := symbols[rnd.IntN(len(symbols))]
+		b.WriteString(sym)
+		seg := newSegment(sym, 0)
+
+		keys := key.consKeys
+		if seg.Kind == SegmentVowel {
+			keys = key.vowelKeys
+		}
+		if rnd.IntN(2) == 0 {
+			k := keys[rnd.IntN(len(keys))]
+			if strings.Contains(k.accepts, seg.Symbol) {
+				b.WriteString(k.code)
+			}
+		}
+		for _, code := range []string{",,", ",", "."} {
+			if rnd.IntN(4) == 0 {
+				b.WriteString(code)
+				break
+			}
+		}
+		if rnd.IntN(5) == 0 {
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+func TestUntranscribe(t *testing.T) {
+	tests := map[string]string{
+		"a\u0306b": "a94b",
+		"ʃta":      "s+ta",
+		"tʂ̻":      "c7",
+		"ʃʲ":       "s+8",
+		"ˈa̞ː":     "a4.,,",
+		"aʔ":       "aʔ",
+		"a.":       "a95",
+		"ʔəts":     "953c",
+		"n̩ ɪ":     "n, 1",
+		"\u1eb5":   "a94+", // ẵ, as NFC gives it
+		"ˌe.,,":    "e,95,,",
+	}
+	for ipa, want := range tests {
+		if got := Untranscribe(ipa); got != want {
+			t.Errorf("Untranscribe(%q) = %q, want %q", ipa, got, want)
+		}
+	}
+}
+
+// TestUntranscribeRoundTrip checks that transcribing the key notation
+// recovered from IPA gives back the same IPA, in either normalization form.
+func TestUntranscribeRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for range 2000 {
+		in := randomPhonetic(rnd)
+		for _, opts := range []Options{{}, {Normalization: NFC}} {
+			ipa := Transcribe(in, opts).IPA
+			back := Untranscribe(ipa)
+			if got := Transcribe(back, opts).IPA; got != ipa {
+				t.Errorf("Transcribe(%q) in %s = %q, Untranscribe gave %q which transcribes to %q", in, opts.Normalization, ipa, back, got)
+			}
+		}
+	}
+}
+
+func TestAPIUntranscribe(t *testing.T) {
+	body, _ := json.Marshal(dataSignal{Data: "ʃˈa\u0306 tʂ̻ʲ"})
+	w := httptest.NewRecorder()
+	APIUntranscribe(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type %q, want text/plain", ct)
+	}
+	if got, want := w.Body.String(), "s+a94,, c78"; got != want {
+		t.Errorf("body %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sammyshear/lcaaj-transcriber/views"
+	datastar "github.com/starfederation/datastar/sdk/go"
+)
+
+// uploadSignal is the file chosen on the page. The signals are local to the
+// page, so that the file is not sent with every transcription request.
+type uploadSignal struct {
+	// Upload holds the contents of the files chosen, base64 encoded, and
+	// UploadNames their names. Only the first is transcribed.
+	Upload      []string `json:"_upload"`
+	UploadNames []string `json:"_uploadNames"`
+	// Column is the column of a table holding the notation, as in
+	// TableOptions.
+	Column    string `json:"_column"`
+	Alphabet  string `json:"alphabet,omitempty"`
+	Normalize string `json:"normalize,omitempty"`
+}
+
+// progressInterval is how often DatastarUpload reports its progress.
+const progressInterval = 200 * time.Millisecond
+
+// DatastarUpload transcribes the file chosen on the page, a table if its
+// name ends in .csv or .tsv and one response per line otherwise, reporting
+// its progress, and then offers the result for download.
+func DatastarUpload(w http.ResponseWriter, r *http.Request) {
+	data := &uploadSignal{}
+	// The file is base64 encoded, which takes a third more room.
+	r.Body = http.MaxBytesReader(w, r.Body, MaxTableSize/3*4+MaxBodySize)
+	if err := datastar.ReadSignals(r, data); err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			writeError(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", MaxTableSize))
+			return
+		}
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	sse := datastar.NewSSE(w, r)
+	status := func(info views.UploadInfo) {
+		sse.MergeFragmentTempl(views.UploadStatus(info), datastar.WithSelectorID("upload-status"))
+	}
+	fail := func(err error) {
+		status(views.UploadInfo{Error: err.Error()})
+	}
+	if len(data.Upload) == 0 {
+		fail(errors.New("choose or drop a file first"))
+		return
+	}
+	in, err := base64.StdEncoding.DecodeString(data.Upload[0])
+	if err != nil {
+		fail(fmt.Errorf("reading the file: %w", err))
+		return
+	}
+	name := "upload.txt"
+	if len(data.UploadNames) > 0 && data.UploadNames[0] != "" {
+		name = path.Base(data.UploadNames[0])
+	}
+	opts, err := requestOptions(data.Alphabet, data.Normalize)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// Lines are only an estimate of the rows of a table, whose fields may
+	// hold line breaks.
+	total := bytes.Count(in, []byte("\n"))
+	if len(in) > 0 && in[len(in)-1] != '\n' {
+		total++
+	}
+	last := time.Now()
+	progress := func(rows int) {
+		if time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+		status(views.UploadInfo{Name: name, Done: min(rows, total), Total: total})
+	}
+	status(views.UploadInfo{Name: name, Total: total})
+
+	var out bytes.Buffer
+	ext := strings.ToLower(path.Ext(name))
+	resultName := strings.TrimSuffix(name, path.Ext(name)) + ".ipa" + ext
+	contentType := "text/plain; charset=utf-8"
+	switch ext {
+	case ".csv", ".tsv":
+		f := TableFormat(ext[1:])
+		contentType = f.ContentType()
+		err = TranscribeTable(r.Context(), &out, bytes.NewReader(in), TableOptions{
+			Format:   f,
+			Column:   data.Column,
+			Options:  opts,
+			Progress: progress,
+		})
+	default:
+		resultName = strings.TrimSuffix(name, path.Ext(name)) + ".ipa.txt"
+		err = transcribeLines(r.Context(), &out, bytes.NewReader(in), opts, progress)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	id, err := results.add(result{name: resultName, contentType: contentType, content: out.Bytes()})
+	if err != nil {
+		fail(err)
+		return
+	}
+	status(views.UploadInfo{
+		Name:     name,
+		Done:     total,
+		Total:    total,
+		Download: downloadURL(r, id),
+		Result:   resultName,
+	})
+}
+
+// transcribeLines transcribes each line of r as the command line tool does,
+// writing one transcription per line.
+func transcribeLines(ctx context.Context, w io.Writer, r io.Reader, opts Options, progress func(lines int)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, MaxBodySize)
+	bw := bufio.NewWriter(w)
+	for n := 1; sc.Scan(); n++ {
+		res, err := TranscribeContext(ctx, strings.TrimSuffix(sc.Text(), "\r"), opts)
+		if err != nil {
+			return err
+		}
+		bw.WriteString(res.Inline())
+		bw.WriteByte('\n')
+		progress(n)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// downloadURL returns the URL path of the result with the given ID, next to
+// the upload request r.
+func downloadURL(r *http.Request, id string) string {
+	p := r.URL.Path
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		p = u.Path
+	}
+	return path.Dir(p) + "/download/" + id
+}
+
+// APIDownload serves a result of DatastarUpload.
+func APIDownload(w http.ResponseWriter, r *http.Request) {
+	res, ok := results.get(chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusNotFound, errors.New("no such result; it may have expired, so transcribe the file again"))
+		return
+	}
+	w.Header().Set("Content-Type", res.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(res.name)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Write(res.content)
+}
+
+const (
+	// resultTTL is how long a result of DatastarUpload can be downloaded.
+	resultTTL = 15 * time.Minute
+	// maxResultBytes bounds the memory results take together. The oldest
+	// are dropped to make room.
+	maxResultBytes = 8 * MaxTableSize
+)
+
+// result is a transcribed file waiting to be downloaded.
+type result struct {
+	name        string
+	contentType string
+	content     []byte
+	expires     time.Time
+}
+
+// resultCache keeps results in memory until they expire.
+type resultCache struct {
+	mu    sync.Mutex
+	byID  map[string]*result
+	order []string
+	size  int
+}
+
+var results = &resultCache{byID: map[string]*result{}}
+
+func (c *resultCache) add(res result) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(b)
+	res.expires = time.Now().Add(resultTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire()
+	for len(c.order) > 0 && c.size+len(res.content) > maxResultBytes {
+		c.drop()
+	}
+	c.byID[id] = &res
+	c.order = append(c.order, id)
+	c.size += len(res.content)
+	return id, nil
+}
+
+func (c *resultCache) get(id string) (*result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire()
+	res, ok := c.byID[id]
+	return res, ok
+}
+
+// expire drops the results that have expired, which are the oldest.
+func (c *resultCache) expire() {
+	now := time.Now()
+	for len(c.order) > 0 && now.After(c.byID[c.order[0]].expires) {
+		c.drop()
+	}
+}
+
+// drop drops the oldest result.
+func (c *resultCache) drop() {
+	id := c.order[0]
+	c.size -= len(c.byID[id].content)
+	delete(c.byID, id)
+	c.order = c.order[1:]
+}
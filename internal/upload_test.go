@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDatastarUpload(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Post("/api/dupload", DatastarUpload)
+	mux.Get("/api/download/{id}", APIDownload)
+
+	upload := func(name, content string) string {
+		t.Helper()
+		body, _ := json.Marshal(uploadSignal{
+			Upload:      []string{base64.StdEncoding.EncodeToString([]byte(content))},
+			UploadNames: []string{name},
+			Column:      "form",
+		})
+		r := httptest.NewRequest(http.MethodPost, "/api/dupload", strings.NewReader(string(body)))
+		r.Header.Set("Datastar-Request", "true")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		link := regexp.MustCompile(`href="(/api/download/[0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+		if link == nil {
+			t.Fatalf("upload of %s offered no download: %s", name, w.Body)
+		}
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link[1], nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("download of %s: status %d", name, w.Code)
+		}
+		return w.Body.String()
+	}
+
+	ipa := func(s string) string { return Transcribe(s, Options{}).IPA }
+	if got, want := upload("r.txt", "a94\r\nu\n"), ipa("a94")+"\n"+ipa("u")+"\n"; got != want {
+		t.Errorf("text file: got %q, want %q", got, want)
+	}
+	if got, want := upload("r.csv", "n,form\n1,a94\n"), "n,form,ipa,annotations\n1,a94,"+ipa("a94")+",\n"; got != want {
+		t.Errorf("table: got %q, want %q", got, want)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/download/0000", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown result: status %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
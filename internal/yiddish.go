@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// yiddishAlphabet spells transcriptions in standard YIVO Yiddish orthography,
+// so that readers of Yiddish can recognize the word behind a dialectal form.
+// It is a best effort: the orthography spells words, not sounds, so only the
+// sounds are followed. Diacritics, stress and length have no spelling and
+// are dropped, as are codes a segment did not accept.
+type yiddishAlphabet struct{}
+
+func (yiddishAlphabet) Name() string  { return "yiddish" }
+func (yiddishAlphabet) Title() string { return "Yiddish (Hebrew script)" }
+
+func (yiddishAlphabet) Render(nodes []Node) string {
+	spelled := map[Node]string{}
+	var word []Node
+	for _, n := range nodes {
+		if isYiddishWordPart(n) {
+			word = append(word, n)
+			continue
+		}
+		spellWord(word, spelled)
+		word = nil
+	}
+	spellWord(word, spelled)
+
+	o := &output{}
+	writePhonetic(o, nodes, func(s *Segment) {
+		o.write(spelled[s], Span{s.Pos, s.Len})
+	}, func(t *Text) {
+		if s, ok := spelled[t]; ok {
+			o.write(s, Span{t.Pos, len(t.Source)})
+			return
+		}
+		o.write(t.Value, Span{t.Pos, len(t.Source)})
+	})
+	return o.b.String()
+}
+
+// isYiddishWordPart reports whether n is part of a word: a segment, or text
+// of letters such as IPA typed directly, as ʃ in mentʃ.
+func isYiddishWordPart(n Node) bool {
+	switch n := n.(type) {
+	case *Segment:
+		return true
+	case *Text:
+		return !n.Unknown && n.Value != "" && strings.IndexFunc(n.Value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r)
+		}) < 0
+	}
+	return false
+}
+
+// The Hebrew points and Yiddish digraph letters, named so they can be told
+// apart in source.
+const (
+	pasekh   = "\u05B7"
+	komets   = "\u05B8"
+	khirik   = "\u05B4"
+	dagesh   = "\u05BC"
+	rafe     = "\u05BF"
+	tsveyVov = "װ"
+	vovYud   = "ױ"
+	tsveyYud = "ײ"
+)
+
+// yiddishLetters spells the sounds that are always written the same way.
+var yiddishLetters = []symbolRule{
+	{"b", "ב"}, {"v", tsveyVov}, {"w", tsveyVov}, {"g", "ג"}, {"d", "ד"},
+	{"h", "ה"}, {"z", "ז"}, {"x", "כ"}, {"t", "ט"}, {"j", "י"},
+	{"k", "ק"}, {"l", "ל"}, {"m", "מ"}, {"n", "נ"}, {"s", "ס"},
+	{"p", "פ" + dagesh}, {"f", "פ" + rafe}, {"r", "ר"},
+	{"ʃ", "ש"}, {"ʂ", "ש"}, {"ʒ", "זש"}, {"ʐ", "זש"}, {"ʔ", ""},
+}
+
+// yiddishFinals are the letters that take another form at the end of a
+// word.
+var yiddishFinals = []symbolRule{
+	{"כ", "ך"}, {"מ", "ם"}, {"נ", "ן"}, {"פ" + rafe, "ף"}, {"צ", "ץ"},
+}
+
+// spellWord spells the segments and text of one word, recording the
+// spelling of each in spelled. A letter that spells more than one sound, as
+// צ does for ts, is recorded with the first node it spells.
+func spellWord(word []Node, spelled map[Node]string) {
+	// The sounds of the word and the node each comes from.
+	var sounds []string
+	var from []Node
+	for _, n := range word {
+		spelled[n] = ""
+		var symbols string
+		switch n := n.(type) {
+		case *Segment:
+			symbols = n.Base
+		case *Text:
+			symbols = n.Value
+		}
+		for _, r := range symbols {
+			if !unicode.Is(unicode.Mn, r) {
+				sounds = append(sounds, string(r))
+				from = append(from, n)
+			}
+		}
+	}
+	at := func(i int) string {
+		if i < 0 || i >= len(sounds) {
+			return ""
+		}
+		return sounds[i]
+	}
+
+	last := -1
+	initial := true
+	for i := 0; i < len(sounds); {
+		spelling, n := spellSound(sounds[i], at(i-1), at(i+1), at(i+2), initial)
+		spelled[from[i]] += spelling
+		if spelling != "" {
+			last = i
+			initial = false
+		}
+		i += n
+	}
+	if last < 0 {
+		return
+	}
+	s := from[last]
+	for _, f := range yiddishFinals {
+		if before, ok := strings.CutSuffix(spelled[s], f.from); ok {
+			spelled[s] = before + f.to
+			break
+		}
+	}
+}
+
+// spellSound spells sound given the sounds around it, and returns how many
+// sounds the spelling covers.
+func spellSound(sound, prev, next, after string, initial bool) (string, int) {
+	// A vowel at the start of a word that is written with a vov or yud
+	// takes a silent alef.
+	alef := ""
+	if initial {
+		alef = "א"
+	}
+	switch sound {
+	case "t":
+		switch next {
+		case "s":
+			return "צ", 2
+		case "ʃ", "ʂ":
+			return "טש", 2
+		}
+	case "d":
+		if next == "ʒ" || next == "ʐ" {
+			return "דזש", 2
+		}
+	case "a", "e", "ə", "o", "ʌ":
+		if next == "j" && !isYiddishVowel(after) {
+			switch sound {
+			case "a":
+				return alef + tsveyYud + pasekh, 2
+			case "e", "ə":
+				return alef + tsveyYud, 2
+			default:
+				return alef + vovYud, 2
+			}
+		}
+		switch sound {
+		case "a":
+			return "א" + pasekh, 1
+		case "e", "ə":
+			return "ע", 1
+		default:
+			return "א" + komets, 1
+		}
+	case "u":
+		switch {
+		case initial:
+			return "או", 1
+		case prev == "v" || prev == "w" || prev == "u":
+			return "ו" + dagesh, 1
+		}
+		return "ו", 1
+	case "i", "ɪ":
+		switch {
+		case initial:
+			return "אי", 1
+		case prev == "j" || next == "j" || isYiddishVowel(prev):
+			return "י" + khirik, 1
+		}
+		return "י", 1
+	}
+	if spelling, ok := lookupRule(yiddishLetters, sound); ok {
+		return spelling, 1
+	}
+	return sound, 1
+}
+
+func isYiddishVowel(sound string) bool {
+	return sound != "" && strings.Contains(key.vowelSymbols, sound)
+}
@@ -1,23 +1,198 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/a-h/templ"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/sammyshear/lcaaj-transcriber/internal"
+	"github.com/sammyshear/lcaaj-transcriber/static"
 	"github.com/sammyshear/lcaaj-transcriber/views"
 )
 
+// config is the server's configuration, from flags and the environment.
+type config struct {
+	keyPath         string
+	addr            string
+	tlsCert         string
+	tlsKey          string
+	basePath        string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	requestTimeout  time.Duration
+	dbPath          string
+	shutdownTimeout time.Duration
+}
+
+// parseConfig reads the configuration from the command-line arguments args,
+// without the program name, and the environment variables found by lookup.
+// Flags take precedence over the environment.
+func parseConfig(args []string, lookup func(string) (string, bool)) (config, error) {
+	var errs []error
+	envOr := func(name, def string) string {
+		if v, ok := lookup(name); ok {
+			return v
+		}
+		return def
+	}
+	envDuration := func(name string, def time.Duration) time.Duration {
+		v, ok := lookup(name)
+		if !ok {
+			return def
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		return d
+	}
+
+	var c config
+	fs := flag.NewFlagSet("lcaaj-transcriber", flag.ContinueOnError)
+	fs.StringVar(&c.keyPath, "key", envOr("LCAAJ_KEY", ""), "transcription key file to use instead of the built-in key (env LCAAJ_KEY)")
+	fs.StringVar(&c.addr, "addr", envOr("LCAAJ_ADDR", ":8080"), "address to listen on (env LCAAJ_ADDR)")
+	fs.StringVar(&c.tlsCert, "tls-cert", envOr("LCAAJ_TLS_CERT", ""), "TLS certificate file; serves HTTPS if set with -tls-key (env LCAAJ_TLS_CERT)")
+	fs.StringVar(&c.tlsKey, "tls-key", envOr("LCAAJ_TLS_KEY", ""), "TLS private key file (env LCAAJ_TLS_KEY)")
+	fs.StringVar(&c.basePath, "base-path", envOr("LCAAJ_BASE_PATH", ""), "URL path prefix the app is served under, e.g. /lcaaj behind a reverse proxy (env LCAAJ_BASE_PATH)")
+	fs.DurationVar(&c.readTimeout, "read-timeout", envDuration("LCAAJ_READ_TIMEOUT", 15*time.Second), "longest time to read a request (env LCAAJ_READ_TIMEOUT)")
+	fs.DurationVar(&c.writeTimeout, "write-timeout", envDuration("LCAAJ_WRITE_TIMEOUT", time.Minute), "longest time to write a response, including event streams (env LCAAJ_WRITE_TIMEOUT)")
+	fs.DurationVar(&c.requestTimeout, "request-timeout", envDuration("LCAAJ_REQUEST_TIMEOUT", 30*time.Second), "how long a request may take before it is cancelled with a 503 error; keep it below -write-timeout, which cuts the connection (env LCAAJ_REQUEST_TIMEOUT)")
+	fs.StringVar(&c.dbPath, "db", envOr("LCAAJ_DB", ""), "SQLite database to save records in; records are not saved if unset (env LCAAJ_DB)")
+	fs.DurationVar(&c.shutdownTimeout, "shutdown-timeout", envDuration("LCAAJ_SHUTDOWN_TIMEOUT", 30*time.Second), "how long to let requests in flight finish on shutdown (env LCAAJ_SHUTDOWN_TIMEOUT)")
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if len(errs) > 0 {
+		return config{}, errors.Join(errs...)
+	}
+	if (c.tlsCert == "") != (c.tlsKey == "") {
+		return config{}, errors.New("-tls-cert and -tls-key must be given together")
+	}
+	c.basePath = strings.TrimSuffix(c.basePath, "/")
+	if c.basePath != "" && !strings.HasPrefix(c.basePath, "/") {
+		c.basePath = "/" + c.basePath
+	}
+	return c, nil
+}
+
 func main() {
+	c, err := parseConfig(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if c.keyPath != "" {
+		k, err := internal.LoadKey(c.keyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		internal.SetKey(k)
+	}
+
+	var store *internal.Store
+	if c.dbPath != "" {
+		var err error
+		if store, err = internal.OpenStore(c.dbPath); err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+	}
+
+	srv := &http.Server{
+		Addr:              c.addr,
+		Handler:           newHandler(c, store),
+		ReadTimeout:       c.readTimeout,
+		ReadHeaderTimeout: c.readTimeout,
+		WriteTimeout:      c.writeTimeout,
+	}
+	ln, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	log.Printf("listening on %s%s", c.addr, c.basePath)
+	if err := serve(ctx, srv, ln, c); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newHandler returns the handler of the app as configured by c. Records are
+// saved in store unless it is nil.
+func newHandler(c config, store *internal.Store) http.Handler {
 	mux := chi.NewMux()
+	mux.Use(middleware.RequestID, internal.Recoverer, internal.Timeout(c.requestTimeout))
+	mux.NotFound(internal.NotFound)
+	mux.MethodNotAllowed(internal.MethodNotAllowed)
+
+	if store != nil {
+		mux.Mount("/api/records", internal.RecordsHandler(store))
+		mux.Post("/api/dsave", internal.DatastarSave(store))
+	}
 
-	mux.Handle("/", templ.Handler(views.IndexPage()))
+	static.Add("templ.css", views.Stylesheet())
+	mux.Handle("/static/*", http.StripPrefix("/static", static.Handler()))
+	mux.Handle("/", templ.Handler(views.IndexPage(views.PageInfo{BasePath: c.basePath}, internal.AlphabetOptions(), store != nil)))
 	mux.Post("/api/transcribe", internal.APITranscribe)
+	mux.Post("/api/transcribe/batch", internal.APITranscribeBatch)
+	mux.Post("/api/transcribe/records", internal.APITranscribeRecords)
+	mux.Post("/api/transcribe/table", internal.APITranscribeTable)
+	mux.Post("/api/untranscribe", internal.APIUntranscribe)
 	mux.HandleFunc("/api/dtranscribe", internal.DatastarTranscribe)
+	mux.Post("/api/dupload", internal.DatastarUpload)
+	mux.Get("/api/download/{id}", internal.APIDownload)
+
+	var handler http.Handler = templ.NewCSSMiddleware(mux, views.SharedClasses()...)
+	if c.basePath != "" {
+		handler = http.StripPrefix(c.basePath, handler)
+	}
+	return handler
+}
+
+// serve serves srv on ln, over TLS if c sets a certificate, until ctx is
+// done. It then stops accepting connections and waits up to
+// c.shutdownTimeout for those in flight, including event streams, to finish.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, c config) error {
+	errc := make(chan error, 1)
+	go func() {
+		if c.tlsCert != "" {
+			errc <- srv.ServeTLS(ln, c.tlsCert, c.tlsKey)
+		} else {
+			errc <- srv.Serve(ln)
+		}
+	}()
 
-	handler := templ.NewCSSMiddleware(mux, views.MainClass(), views.InputClass())
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
 
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	log.Print("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sammyshear/lcaaj-transcriber/static"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want func(*config)
+		err  bool
+	}{
+		{name: "defaults", want: func(c *config) {}},
+		{
+			name: "environment",
+			env:  map[string]string{"LCAAJ_ADDR": ":9000", "LCAAJ_DB": "lcaaj.db", "LCAAJ_REQUEST_TIMEOUT": "5s"},
+			want: func(c *config) { c.addr, c.dbPath, c.requestTimeout = ":9000", "lcaaj.db", 5*time.Second },
+		},
+		{
+			name: "flags over environment",
+			args: []string{"-addr", ":9001", "-write-timeout", "2m"},
+			env:  map[string]string{"LCAAJ_ADDR": ":9000", "LCAAJ_WRITE_TIMEOUT": "10s"},
+			want: func(c *config) { c.addr, c.writeTimeout = ":9001", 2*time.Minute },
+		},
+		{name: "base path", args: []string{"-base-path", "/lcaaj"}, want: func(c *config) { c.basePath = "/lcaaj" }},
+		{name: "base path without slash", args: []string{"-base-path", "lcaaj"}, want: func(c *config) { c.basePath = "/lcaaj" }},
+		{name: "base path with trailing slash", env: map[string]string{"LCAAJ_BASE_PATH": "/lcaaj/"}, want: func(c *config) { c.basePath = "/lcaaj" }},
+		{name: "root base path", args: []string{"-base-path", "/"}, want: func(c *config) {}},
+		{name: "bad duration", env: map[string]string{"LCAAJ_READ_TIMEOUT": "soon"}, err: true},
+		{name: "bad flag", args: []string{"-read-timeout", "soon"}, err: true},
+		{name: "unknown flag", args: []string{"-port", "80"}, err: true},
+		{name: "certificate without key", args: []string{"-tls-cert", "cert.pem"}, err: true},
+	}
+	for _, tt := range tests {
+		lookup := func(name string) (string, bool) {
+			v, ok := tt.env[name]
+			return v, ok
+		}
+		got, err := parseConfig(tt.args, lookup)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: parseConfig gave %+v, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		want := config{
+			addr:            ":8080",
+			readTimeout:     15 * time.Second,
+			writeTimeout:    time.Minute,
+			requestTimeout:  30 * time.Second,
+			shutdownTimeout: 30 * time.Second,
+		}
+		tt.want(&want)
+		if got != want {
+			t.Errorf("%s: parseConfig = %+v, want %+v", tt.name, got, want)
+		}
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	h := newHandler(config{basePath: "/lcaaj", requestTimeout: time.Minute}, nil)
+	tests := []struct {
+		method, path string
+		body         string
+		status       int
+		want         string
+	}{
+		{http.MethodPost, "/lcaaj/api/transcribe", `{"data": "s+"}`, http.StatusOK, "ʃ"},
+		{http.MethodGet, "/lcaaj" + "/static" + static.Path("site.css"), "", http.StatusOK, "body"},
+		{http.MethodGet, "/lcaaj/", "", http.StatusOK, `href="/lcaaj/static/`},
+		{http.MethodPost, "/api/transcribe", `{"data": "s+"}`, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, w.Code, tt.status)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s %s: body does not contain %q:\n%.200s", tt.method, tt.path, tt.want, w.Body)
+		}
+	}
+}
+
+// TestServeShutdown checks that a request in flight when the server is asked
+// to stop still gets its response.
+func TestServeShutdown(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, srv, ln, config{shutdownTimeout: 10 * time.Second})
+	}()
+
+	type response struct {
+		body string
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		responses <- response{string(b), err}
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned %v with a request in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if r := <-responses; r.err != nil || r.body != "done" {
+		t.Errorf("request in flight got %q, %v; want it to finish", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve = %v, want nil after shutdown", err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Error("server still accepts requests after shutdown")
+	}
+}
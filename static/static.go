@@ -0,0 +1,159 @@
+// Package static serves the assets the pages load: the Datastar bundle, the
+// stylesheets and the fonts. They are built into the binary, so the app
+// works without network access and renders the IPA with the same fonts
+// everywhere.
+//
+// Each asset is served both under its own name and under a name that
+// includes a hash of its content, as returned by Path. Pages link to the
+// hashed names, which can be cached for good since new content gets a new
+// name; the plain names are revalidated on every use.
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// files holds the assets. datastar.js is the bundle of the Datastar version
+// the server's SDK speaks, from the SDK's own module.
+//
+//go:embed datastar.js site.css fonts
+var files embed.FS
+
+// asset is one file and the name it is served under with its hash.
+type asset struct {
+	content []byte
+	hashed  string
+}
+
+var (
+	mu sync.RWMutex
+	// assets maps the plain name of each asset to it.
+	assets = map[string]*asset{}
+	// hashed maps the hashed name of each asset to its plain name.
+	hashed = map[string]string{}
+)
+
+func init() {
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Base(name) == "README.md" {
+			return err
+		}
+		b, err := files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		Add(name, b)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	Add("fonts.css", fontFaces())
+}
+
+// Add makes content available as the asset name, replacing any asset of
+// that name. It is for stylesheets generated when the server starts.
+func Add(name string, content []byte) {
+	sum := sha256.Sum256(content)
+	ext := path.Ext(name)
+	a := &asset{
+		content: content,
+		hashed:  strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:])[:8] + ext,
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if old, ok := assets[name]; ok {
+		delete(hashed, old.hashed)
+	}
+	assets[name] = a
+	hashed[a.hashed] = name
+}
+
+// Path returns the URL path of the asset name relative to where Handler is
+// mounted, including the hash of its content. Unknown assets get their plain
+// name.
+func Path(name string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if a, ok := assets[name]; ok {
+		return "/" + a.hashed
+	}
+	return "/" + name
+}
+
+// Handler serves the assets.
+func Handler() http.Handler {
+	return http.HandlerFunc(serve)
+}
+
+// startTime is the modification time given for every asset, so that
+// If-Modified-Since works as well as ETags.
+var startTime = time.Now()
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+
+	mu.RLock()
+	a, ok := assets[name]
+	immutable := false
+	if !ok {
+		if plain, isHashed := hashed[name]; isHashed {
+			a, ok, immutable = assets[plain], true, true
+		}
+	}
+	mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if immutable {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", fmt.Sprintf("%q", a.hashed))
+	http.ServeContent(w, r, name, startTime, bytes.NewReader(a.content))
+}
+
+// fontFiles lists the faces of STIX Two Text that fonts.css declares. STIX
+// Two Text covers the IPA and the combining marks the key uses, which many
+// system serif fonts do not.
+var fontFiles = []struct {
+	file   string
+	weight int
+	style  string
+}{
+	{"STIXTwoText-Regular.woff2", 400, "normal"},
+	{"STIXTwoText-Italic.woff2", 400, "italic"},
+	{"STIXTwoText-Bold.woff2", 700, "normal"},
+	{"STIXTwoText-BoldItalic.woff2", 700, "italic"},
+}
+
+// fontFaces returns the @font-face rules for the fonts built in.
+func fontFaces() []byte {
+	var b bytes.Buffer
+	for _, f := range fontFiles {
+		name := "fonts/" + f.file
+		if _, ok := assets[name]; !ok {
+			panic("static: missing font " + name)
+		}
+		// fonts.css is served next to the fonts directory, so a relative
+		// URL works wherever Handler is mounted.
+		url := strings.TrimPrefix(Path(name), "/")
+		fmt.Fprintf(&b, "@font-face{font-family:\"STIX Two Text\";font-weight:%d;font-style:%s;font-display:swap;src:url(%q) format(\"woff2\")}\n", f.weight, f.style, url)
+	}
+	return b.Bytes()
+}
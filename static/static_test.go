@@ -0,0 +1,73 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	Add("test.css", []byte("body{}"))
+	hashedPath := Path("test.css")
+	if hashedPath == "/test.css" || !strings.HasPrefix(hashedPath, "/test.") || !strings.HasSuffix(hashedPath, ".css") {
+		t.Fatalf("Path(test.css) = %q, want a hashed name", hashedPath)
+	}
+
+	tests := []struct {
+		path        string
+		ifNoneMatch string
+		status      int
+		cache       string
+	}{
+		{hashedPath, "", http.StatusOK, "public, max-age=31536000, immutable"},
+		{"/test.css", "", http.StatusOK, "no-cache"},
+		{"/test.css", `"` + hashedPath[1:] + `"`, http.StatusNotModified, "no-cache"},
+		{"/datastar.js", "", http.StatusOK, "no-cache"},
+		{"/missing.css", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, w.Code, tt.status)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tt.cache {
+			t.Errorf("GET %s: Cache-Control %q, want %q", tt.path, got, tt.cache)
+		}
+	}
+
+	Add("test.css", []byte("main{}"))
+	if Path("test.css") == hashedPath {
+		t.Error("Path did not change with the content")
+	}
+}
+
+func TestFontFaces(t *testing.T) {
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path("fonts.css"), nil))
+	css := w.Body.String()
+	urls := regexp.MustCompile(`@font-face\{[^}]*src:url\("([^"]+)"\)`).FindAllStringSubmatch(css, -1)
+	if len(urls) != len(fontFiles) {
+		t.Fatalf("fonts.css declares %d faces, want %d:\n%s", len(urls), len(fontFiles), css)
+	}
+	for i, m := range urls {
+		want := Path("fonts/" + fontFiles[i].file)
+		if "/"+m[1] != want {
+			t.Errorf("face %d has URL %q, want the hashed path %q", i, m[1], want)
+		}
+		w := httptest.NewRecorder()
+		Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+m[1], nil))
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "font/woff2" || !strings.HasPrefix(w.Body.String(), "wOF2") {
+			t.Errorf("GET /%s: status %d, type %q, want a WOFF2 font", m[1], w.Code, w.Header().Get("Content-Type"))
+		}
+		if got := w.Header().Get("Cache-Control"); !strings.Contains(got, "immutable") {
+			t.Errorf("GET /%s: Cache-Control %q, want it cached for good", m[1], got)
+		}
+	}
+}
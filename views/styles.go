@@ -0,0 +1,30 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/a-h/templ"
+)
+
+// SharedClasses are the classes of the pages and the fragments merged into
+// them. They are served in one stylesheet, templ.css, instead of in a style
+// element in each page and fragment.
+func SharedClasses() []templ.CSSClass {
+	return []templ.CSSClass{
+		MainClass(), TipClass(), AnnotationClass(), SourceClass(),
+		ErrorMarkClass(), WarningMarkClass(), InfoMarkClass(), HoverClass(),
+		InputClass(), EditorClass(), LinesClass(), LineClass(), DropClass(),
+	}
+}
+
+// Stylesheet returns templ.css, the CSS of SharedClasses.
+func Stylesheet() []byte {
+	var b bytes.Buffer
+	for _, c := range SharedClasses() {
+		if c, ok := c.(templ.ComponentCSSClass); ok {
+			fmt.Fprintln(&b, c.Class)
+		}
+	}
+	return b.Bytes()
+}